@@ -1,47 +1,173 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"github.com/google/uuid"
 	"golang-server/internal/api"
+	"golang-server/internal/auth"
 	"golang-server/internal/config"
+	"golang-server/internal/fees"
+	"golang-server/internal/limits"
+	"golang-server/internal/logging"
+	"golang-server/internal/metrics"
+	"golang-server/internal/model"
+	"golang-server/internal/ratelimit"
 	"golang-server/internal/server"
+	"golang-server/internal/service"
+	"golang-server/internal/storage"
+	"golang-server/internal/storage/memory"
 	"golang-server/internal/storage/postgres"
-	"log"
+	"golang-server/internal/storage/sqlite"
+	"golang-server/internal/tracing"
+	"golang-server/internal/validation"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Сведения о сборке, задаются при компиляции:
+//
+//	go build -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse HEAD)" ./cmd
+var (
+	version = "dev"
+	commit  = ""
+)
+
+var (
+	configFlag  = flag.String("config", "", "путь к файлу конфигурации")
+	storageFlag = flag.String("storage", "", "хранилище данных: postgres, sqlite или memory (по умолчанию driver из конфигурации)")
 )
 
 func main() {
+	flag.Parse()
+
 	configPath := getConfigPath()
 	configFile, err := openConfigFile(configPath)
 	if err != nil {
-		log.Fatalf("Не удалось открыть файл конфигурации: %v", err)
+		fatal("failed to open config file", err)
 	}
-	defer closeFile(configFile)
-
 	cfg := config.LoadConfig(configFile)
+	closeFile(configFile)
 
-	db, err := postgres.GetInstance(cfg)
+	logger, err := logging.New(cfg.LogConfig, os.Stderr)
 	if err != nil {
-		log.Fatalf("Ошибка подключения к базе данных: %v", err)
+		fatal("invalid log config", err)
 	}
-	defer closeDB(db)
+	slog.SetDefault(logger)
+
+	storageKind := *storageFlag
+	if storageKind == "" {
+		storageKind = cfg.DbConfig.Driver
+	}
+
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Args(), storageKind, cfg); err != nil {
+			fatal("command failed", err)
+		}
+		return
+	}
+
+	tracer, err := tracing.NewTracer(cfg.Tracing)
+	if err != nil {
+		fatal("invalid tracing config", err)
+	}
+	tracing.SetDefault(tracer)
 
-	router := setupRouter(db)
+	backend, err := setupStorage(storageKind, cfg)
+	if err != nil {
+		fatal("failed to connect to storage", err)
+	}
+
+	if _, err := storage.SeedWallets(context.Background(), backend.wallets, cfg.Seed); err != nil {
+		fatal("failed to seed storage", err)
+	}
+
+	health := api.NewHealthHandler(buildInfo(), backend.checks...)
+	if cfg.Auth.Disabled {
+		slog.Warn("authentication is disabled, every request runs with admin rights")
+	}
+	router, err := setupRouter(backend, health, cfg)
+	if err != nil {
+		fatal("invalid auth config", err)
+	}
 	httpServer := server.NewHTTPServer(cfg.ServerConfig, router)
 
-	log.Println("База данных успешно подключена")
-	log.Printf("Сервер запущен на порту: %s", cfg.ServerConfig.Port)
+	slog.Info("server started", slog.String("storage", storageKind), slog.String("port", cfg.ServerConfig.Port),
+		slog.String("version", version))
+
+	err = serve(httpServer, health, cfg.ServerConfig)
+	// Хранилище закрывается только после завершения всех обработчиков
+	backend.close()
+	shutdownTracer(tracer)
+	if err != nil {
+		fatal("server failed", err)
+	}
+}
+
+// tracerShutdownTimeout ограничивает отправку накопленных span'ов при остановке.
+const tracerShutdownTimeout = 5 * time.Second
+
+// shutdownTracer отправляет накопленные span'ы перед завершением процесса.
+func shutdownTracer(tracer *tracing.Tracer) {
+	ctx, cancel := context.WithTimeout(context.Background(), tracerShutdownTimeout)
+	defer cancel()
+	if err := tracer.Shutdown(ctx); err != nil {
+		slog.Warn("failed to flush spans", slog.String("error", err.Error()))
+	}
+}
+
+// fatal пишет ошибку в лог и завершает процесс с кодом 1.
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.String("error", err.Error()))
+	os.Exit(1)
+}
+
+// serve запускает сервер и при получении SIGINT или SIGTERM останавливает его:
+//  1. /readyz начинает отвечать 503, чтобы балансировщик перестал направлять запросы.
+//  2. После паузы ShutdownDelay сервер перестаёт принимать соединения.
+//  3. Обрабатываемые запросы дорабатывают не дольше ShutdownTimeout.
+//
+// Хранилище закрывается вызывающим кодом уже после возврата, когда обработчики завершились.
+// Повторный сигнал во время остановки завершает процесс немедленно.
+func serve(httpServer *server.HTTPServer, health *api.HealthHandler, cfg config.ServerConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	slog.Info("shutdown signal received, draining")
+	health.SetDraining()
+	time.Sleep(time.Duration(cfg.ShutdownDelay))
 
-	if err := httpServer.ListenAndServe(); err != nil {
-		log.Fatalf("Ошибка запуска сервера: %v", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("requests did not finish within %v: %w", time.Duration(cfg.ShutdownTimeout), err)
 	}
+	slog.Info("server stopped")
+	return nil
 }
 
 // getConfigPath выбирает путь к конфигурационному файлу
 func getConfigPath() string {
-	configFlag := flag.String("config", "", "путь к файлу конфигурации")
-	flag.Parse()
-
 	if *configFlag != "" {
 		return *configFlag
 	}
@@ -59,28 +185,308 @@ func openConfigFile(path string) (*os.File, error) {
 // closeFile закрывает файл с логированием ошибок
 func closeFile(f *os.File) {
 	if err := f.Close(); err != nil {
-		log.Printf("Ошибка закрытия файла: %v", err)
+		slog.Warn("failed to close file", slog.String("error", err.Error()))
 	}
 }
 
 // closeDB закрывает соединение с базой данных
-func closeDB(db *postgres.PgDB) {
+func closeDB(db io.Closer) {
 	if err := db.Close(); err != nil {
-		log.Printf("Ошибка закрытия БД: %v", err)
+		slog.Warn("failed to close database", slog.String("error", err.Error()))
+	}
+}
+
+// storageBackend — подключённое хранилище вместе с проверками готовности и функцией закрытия.
+type storageBackend struct {
+	wallets      storage.WalletStorage
+	transactions storage.TransactionStorage
+	keys         storage.APIKeyStorage
+	checks       []api.DependencyCheck
+	close        func()
+}
+
+// setupStorage создаёт хранилища кошельков и переводов выбранного типа.
+// Функцию close результата нужно вызвать при завершении.
+func setupStorage(kind string, cfg *config.Config) (*storageBackend, error) {
+	switch kind {
+	case "memory":
+		store := memory.NewStore()
+		return &storageBackend{wallets: store, transactions: store, keys: store, close: func() {}}, nil
+	case "postgres":
+		db, err := postgres.GetInstance(cfg)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := postgres.NewMigrator(db.DB)
+		if err != nil {
+			closeDB(db)
+			return nil, err
+		}
+		metrics.Default.RegisterDBStats(db.DB)
+		registerTxRetryMetrics()
+		return &storageBackend{
+			wallets:      storage.NewWalletRepository(db),
+			transactions: storage.NewTransactionRepository(db),
+			keys:         storage.NewAPIKeyRepository(db),
+			checks:       []api.DependencyCheck{api.PingCheck(db.DB), migrationCheck(migrator), api.PoolCheck(db.DB)},
+			close:        func() { closeDB(db) },
+		}, nil
+	case "sqlite":
+		db, err := sqlite.Open(cfg.DbConfig)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Default.RegisterDBStats(db.DB)
+		return &storageBackend{
+			wallets:      sqlite.NewWalletRepository(db),
+			transactions: sqlite.NewTransactionRepository(db),
+			keys:         sqlite.NewAPIKeyRepository(db),
+			checks:       []api.DependencyCheck{api.PingCheck(db.DB), api.PoolCheck(db.DB)},
+			close:        func() { closeDB(db) },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", kind)
+	}
+}
+
+// registerTxRetryMetrics экспортирует счётчики повторов транзакций PostgreSQL.
+func registerTxRetryMetrics() {
+	metrics.Default.NewCounterFunc("db_tx_retries_total", "Total number of transaction retries after serialization conflicts.",
+		func() float64 { return float64(storage.GetTxRetryStats().Retries) })
+	metrics.Default.NewCounterFunc("db_tx_retries_exhausted_total", "Total number of transactions that exhausted their retry budget.",
+		func() float64 { return float64(storage.GetTxRetryStats().Exhausted) })
+}
+
+// migrationCheck проверяет для /readyz, что применены все миграции схемы PostgreSQL.
+func migrationCheck(migrator *postgres.Migrator) api.DependencyCheck {
+	return api.DependencyCheck{
+		Name: "migrations",
+		Check: func(ctx context.Context) (any, error) {
+			current, err := migrator.CheckVersion(ctx)
+			return map[string]int64{"version": current, "expected": migrator.Latest()}, err
+		},
+	}
+}
+
+// buildInfo возвращает версию и коммит сборки. Если коммит не задан через -ldflags,
+// он берётся из сведений о VCS, которые go build встраивает в бинарник.
+func buildInfo() api.BuildInfo {
+	info := api.BuildInfo{Version: version, Commit: commit}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range bi.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// runCommand выполняет служебную команду вместо запуска сервера.
+// Поддерживаются команды:
+//
+//	migrate up     — применить все недостающие миграции
+//	migrate down   — откатить последнюю применённую миграцию
+//	migrate status — показать список миграций и отметку о применении
+//	apikey ...     — выпуск, отзыв и просмотр ключей API, см. runAPIKeyCommand
+func runCommand(args []string, storageKind string, cfg *config.Config) error {
+	if args[0] == "apikey" {
+		return runAPIKeyCommand(args[1:], storageKind, cfg)
+	}
+	if args[0] != "migrate" || len(args) != 2 {
+		return fmt.Errorf("unknown command %q, usage: migrate up|down|status or apikey issue|revoke|list", strings.Join(args, " "))
+	}
+
+	db, err := postgres.Open(cfg.DbConfig)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	migrator, err := postgres.NewMigrator(db.DB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[1] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Info("migrations applied", slog.Int("count", len(applied)), slog.Int64("version", migrator.Latest()))
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			slog.Info("no applied migrations")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-40s  %s\n", st.Version, st.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, usage: migrate up|down|status", args[1])
 	}
+	return nil
+}
+
+// runAPIKeyCommand управляет ключами API в настроенном хранилище:
+//
+//	apikey issue -name NAME -wallet UUID [-wallet UUID ...] — выпустить ключ клиента
+//	apikey issue -name NAME -admin                          — выпустить ключ администратора
+//	apikey revoke ID                                        — отозвать ключ
+//	apikey list                                             — показать все ключи
+//
+// Выпущенный ключ печатается один раз: в хранилище остаётся только его хеш.
+func runAPIKeyCommand(args []string, storageKind string, cfg *config.Config) error {
+	const usage = "usage: apikey issue|revoke|list"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	if storageKind == "memory" {
+		return errors.New("apikey commands need a persistent storage, memory storage keys live only inside the server process")
+	}
+
+	backend, err := setupStorage(storageKind, cfg)
+	if err != nil {
+		return err
+	}
+	defer backend.close()
+	keyService := service.NewAPIKeyService(backend.keys)
+
+	ctx := context.Background()
+	switch args[0] {
+	case "issue":
+		req, err := parseIssueFlags(args[1:])
+		if err != nil {
+			return err
+		}
+		key, err := keyService.Issue(ctx, req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("id:  %s\nkey: %s\n", key.Id, key.Key)
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New("usage: apikey revoke ID")
+		}
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid API key ID: %w", err)
+		}
+		key, err := keyService.Revoke(ctx, id)
+		if err != nil {
+			return err
+		}
+		slog.Info("api key revoked", slog.String("api_key_id", key.Id.String()), slog.Time("revoked_at", *key.RevokedAt))
+	case "list":
+		keys, err := keyService.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			status := "active"
+			if k.RevokedAt != nil {
+				status = "revoked " + k.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%s  %-6s  %-30s  %d wallets  %s\n", k.Id, k.Role, k.Name, len(k.Wallets), status)
+		}
+	default:
+		return fmt.Errorf("unknown apikey command %q, %s", args[0], usage)
+	}
+	return nil
+}
+
+// parseIssueFlags разбирает флаги команды apikey issue и проверяет запрос.
+func parseIssueFlags(args []string) (model.IssueAPIKeyRequest, error) {
+	req := model.IssueAPIKeyRequest{Role: model.APIKeyClient}
+	fs := flag.NewFlagSet("apikey issue", flag.ContinueOnError)
+	fs.StringVar(&req.Name, "name", "", "имя ключа, например название клиента")
+	admin := fs.Bool("admin", false, "выпустить ключ администратора")
+	fs.Func("wallet", "UUID доступного ключу кошелька; флаг повторяется", func(v string) error {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return err
+		}
+		req.Wallets = append(req.Wallets, id)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return req, err
+	}
+	if *admin {
+		req.Role = model.APIKeyAdmin
+	}
+	return req, validation.ValidateIssueAPIKey(req)
 }
 
 // setupRouter настраивает маршруты и middleware
-func setupRouter(db *postgres.PgDB) *api.Router {
-	transactionHandler := api.NewTransactionHandler(db)
-	walletHandler := api.NewWalletHandler(db)
+func setupRouter(backend *storageBackend, health *api.HealthHandler, cfg *config.Config) (*api.Router, error) {
+	engine, err := limits.NewEngine(cfg.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid limits_config: %w", err)
+	}
+	schedule, err := fees.NewSchedule(cfg.Fees)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fees_config: %w", err)
+	}
+	transactionHandler := api.NewTransactionHandler(backend.wallets, backend.transactions, engine, schedule)
+	walletHandler := api.NewWalletHandler(backend.wallets, backend.transactions)
+	keyHandler := api.NewAPIKeyHandler(backend.keys)
 
 	r := api.NewRouter()
-	r.Use(api.RecoveryMiddleware)
+	r.Use(api.MetricsMiddleware)
+	r.Use(api.RequestIDMiddleware)
 	r.Use(api.LoggingMiddleware)
+	r.Use(api.RecoveryMiddleware)
+	if cfg.Auth.Disabled {
+		r.Use(api.NoAuthMiddleware)
+	} else {
+		var tokens *auth.JWTVerifier
+		if cfg.Auth.JWT.JWKSFile != "" {
+			if tokens, err = auth.NewJWTVerifier(cfg.Auth.JWT); err != nil {
+				return nil, err
+			}
+		}
+		r.Use(api.AuthMiddleware(backend.keys, tokens))
+	}
+	if rl := cfg.ServerConfig.RateLimit; rl.ClientRate > 0 || rl.WalletRate > 0 {
+		r.Use(api.RateLimitMiddleware(rl, ratelimit.NewMemoryStore()))
+	}
+	if len(cfg.Signing.Clients) > 0 {
+		signatures, err := api.SignatureMiddleware(cfg.Signing)
+		if err != nil {
+			return nil, err
+		}
+		r.Use(signatures)
+	}
+	r.RegisterRoute("/healthz", health)
+	r.RegisterRoute("/readyz", health)
+	r.RegisterRoute("/api/status", health)
+	r.RegisterRoute("/metrics", metrics.Default.Handler())
 	r.RegisterRoute("/api/send", transactionHandler)
+	r.RegisterRoute("/api/send/quote", transactionHandler)
 	r.RegisterRoute("/api/transactions", transactionHandler)
+	r.RegisterRoute("/api/wallet", walletHandler)
 	r.RegisterRoute("/api/wallet/", walletHandler)
+	r.RegisterRoute("/api/admin/wallet/", walletHandler)
+	r.RegisterRoute("/api/admin/keys", keyHandler)
+	r.RegisterRoute("/api/admin/keys/", keyHandler)
 
-	return r
+	return r, nil
 }
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/config"
+	"golang-server/internal/fees"
+	"golang-server/internal/limits"
+	"golang-server/internal/model"
+	"golang-server/internal/ratelimit"
+	"golang-server/internal/service"
+	"golang-server/internal/storage/memory"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testServer — API поверх хранилища в памяти, собранный так же, как в cmd/main.go.
+type testServer struct {
+	store   *memory.Store
+	handler http.Handler
+}
+
+// newTestServer собирает Router с middleware и маршрутами по конфигурации cfg.
+func newTestServer(t *testing.T, cfg *config.Config) *testServer {
+	t.Helper()
+	cfg.SetDefaults()
+
+	store := memory.NewStore()
+	engine, err := limits.NewEngine(cfg.Limits)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	schedule, err := fees.NewSchedule(cfg.Fees)
+	if err != nil {
+		t.Fatalf("NewSchedule: %v", err)
+	}
+	transactionHandler := NewTransactionHandler(store, store, engine, schedule)
+	walletHandler := NewWalletHandler(store, store)
+	keyHandler := NewAPIKeyHandler(store)
+
+	r := NewRouter()
+	r.Use(RequestIDMiddleware)
+	r.Use(RecoveryMiddleware)
+	if cfg.Auth.Disabled {
+		r.Use(NoAuthMiddleware)
+	} else {
+		var tokens *auth.JWTVerifier
+		if cfg.Auth.JWT.JWKSFile != "" {
+			if tokens, err = auth.NewJWTVerifier(cfg.Auth.JWT); err != nil {
+				t.Fatalf("NewJWTVerifier: %v", err)
+			}
+		}
+		r.Use(AuthMiddleware(store, tokens))
+	}
+	if rl := cfg.ServerConfig.RateLimit; rl.ClientRate > 0 || rl.WalletRate > 0 {
+		r.Use(RateLimitMiddleware(rl, ratelimit.NewMemoryStore()))
+	}
+	if len(cfg.Signing.Clients) > 0 {
+		signatures, err := SignatureMiddleware(cfg.Signing)
+		if err != nil {
+			t.Fatalf("SignatureMiddleware: %v", err)
+		}
+		r.Use(signatures)
+	}
+	r.RegisterRoute("/api/send", transactionHandler)
+	r.RegisterRoute("/api/send/quote", transactionHandler)
+	r.RegisterRoute("/api/transactions", transactionHandler)
+	r.RegisterRoute("/api/wallet", walletHandler)
+	r.RegisterRoute("/api/wallet/", walletHandler)
+	r.RegisterRoute("/api/admin/wallet/", walletHandler)
+	r.RegisterRoute("/api/admin/keys", keyHandler)
+	r.RegisterRoute("/api/admin/keys/", keyHandler)
+
+	return &testServer{store: store, handler: r.Handler()}
+}
+
+// newWallet создаёт активный кошелёк с начальным балансом.
+func (s *testServer) newWallet(t *testing.T, balance string) uuid.UUID {
+	t.Helper()
+
+	wallet := model.Wallet{Id: uuid.New(), Balance: model.MustParseMoney(balance), Status: model.WalletActive}
+	if err := s.store.CreateWallet(t.Context(), &wallet); err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	return wallet.Id
+}
+
+// issueKey выпускает ключ API с ролью role и доступом к wallets и возвращает сам ключ.
+func (s *testServer) issueKey(t *testing.T, role model.APIKeyRole, wallets ...uuid.UUID) string {
+	t.Helper()
+
+	keys := service.NewAPIKeyService(s.store)
+	issued, err := keys.Issue(t.Context(), model.IssueAPIKeyRequest{Name: "test", Role: role, Wallets: wallets})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return issued.Key
+}
+
+// do выполняет запрос к API с ключом или токеном bearer (если он не пуст) и заголовками headers.
+func (s *testServer) do(t *testing.T, method, path, bearer, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode разбирает JSON-ответ в v.
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// errorCode возвращает код ошибки из JSON-ответа.
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var e HTTPError
+	decode(t, rec, &e)
+	return e.Code
+}
+
+// jwtSecret — ключ HS256 тестового JWKS.
+var jwtSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// jwtConfig записывает JWKS с ключом jwtSecret и возвращает настройки проверки токенов.
+func jwtConfig(t *testing.T) config.JWTConfig {
+	t.Helper()
+
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "oct", "kid": "test", "k": base64.RawURLEncoding.EncodeToString(jwtSecret)},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return config.JWTConfig{JWKSFile: path}
+}
+
+// signJWT подписывает токен с утверждениями claims ключом jwtSecret.
+// Если exp не задан, токен действителен час.
+func signJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	h, _ := json.Marshal(map[string]string{"alg": auth.AlgHS256, "kid": "test"})
+	c, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	mac := hmac.New(sha256.New, jwtSecret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
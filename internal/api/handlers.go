@@ -4,17 +4,78 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/domain"
+	"golang-server/internal/fees"
+	"golang-server/internal/limits"
+	"golang-server/internal/logging"
 	"golang-server/internal/model"
 	"golang-server/internal/service"
-	"golang-server/internal/storage/postgres"
+	"golang-server/internal/storage"
 	"golang-server/internal/validation"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
+	"time"
 )
 
-// walletBalanceRegex соответствует пути: /api/wallet/{uuid}/balance
-var walletBalanceRegex = regexp.MustCompile(`^/api/wallet/([a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[1-5][a-fA-F0-9]{3}-[89abAB][a-fA-F0-9]{3}-[a-fA-F0-9]{12})/balance$`)
+const (
+	maxIdempotencyKeyLength = 255 // Максимальная длина заголовка Idempotency-Key
+	defaultPageLimit        = 50  // Размер страницы истории по умолчанию
+	maxPageLimit            = 500 // Максимальный размер страницы истории
+)
+
+// uuidPattern соответствует UUID версий 1–5.
+const uuidPattern = `([a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[1-5][a-fA-F0-9]{3}-[89abAB][a-fA-F0-9]{3}-[a-fA-F0-9]{12})`
+
+var (
+	// walletBalanceRegex соответствует пути: /api/wallet/{uuid}/balance
+	walletBalanceRegex = regexp.MustCompile(`^/api/wallet/` + uuidPattern + `/balance$`)
+	// walletRegex соответствует пути: /api/wallet/{uuid}
+	walletRegex = regexp.MustCompile(`^/api/wallet/` + uuidPattern + `$`)
+	// walletTransactionsRegex соответствует пути: /api/wallet/{uuid}/transactions
+	walletTransactionsRegex = regexp.MustCompile(`^/api/wallet/` + uuidPattern + `/transactions$`)
+	// adminWalletStatusRegex соответствует пути: /api/admin/wallet/{uuid}/status
+	adminWalletStatusRegex = regexp.MustCompile(`^/api/admin/wallet/` + uuidPattern + `/status$`)
+	// adminWalletStatusHistoryRegex соответствует пути: /api/admin/wallet/{uuid}/status-history
+	adminWalletStatusHistoryRegex = regexp.MustCompile(`^/api/admin/wallet/` + uuidPattern + `/status-history$`)
+	// adminKeyRegex соответствует пути: /api/admin/keys/{uuid}
+	adminKeyRegex = regexp.MustCompile(`^/api/admin/keys/` + uuidPattern + `$`)
+)
+
+// uuidRegex находит UUID в пути запроса.
+var uuidRegex = regexp.MustCompile(uuidPattern)
+
+// knownRoutes — шаблоны маршрутов, которые попадают в метку route метрик и в лог.
+// Прочие пути учитываются как "other", чтобы произвольные URL не раздували число серий.
+var knownRoutes = map[string]bool{
+	"/api/send":                             true,
+	"/api/send/quote":                       true,
+	"/api/transactions":                     true,
+	"/api/wallet":                           true,
+	"/api/wallet/{id}":                      true,
+	"/api/wallet/{id}/balance":              true,
+	"/api/wallet/{id}/transactions":         true,
+	"/api/admin/wallet/{id}/status":         true,
+	"/api/admin/wallet/{id}/status-history": true,
+	"/api/admin/keys":                       true,
+	"/api/admin/keys/{id}":                  true,
+	"/api/status":                           true,
+	"/healthz":                              true,
+	"/readyz":                               true,
+	"/metrics":                              true,
+}
+
+// routeLabel приводит путь запроса к шаблону маршрута: UUID заменяются на {id}.
+func routeLabel(path string) string {
+	route := uuidRegex.ReplaceAllString(path, "{id}")
+	if !knownRoutes[route] {
+		return "other"
+	}
+	return route
+}
 
 // TransactionHandler обрабатывает запросы, связанные с транзакциями.
 type TransactionHandler struct {
@@ -26,23 +87,36 @@ type WalletHandler struct {
 	walletService service.WalletService
 }
 
-// NewTransactionHandler создаёт новый обработчик транзакций.
-func NewTransactionHandler(db *postgres.PgDB) *TransactionHandler {
-	return &TransactionHandler{transactionService: service.NewTransactionService(db)}
+// APIKeyHandler обрабатывает административные запросы к ключам API.
+type APIKeyHandler struct {
+	keyService service.APIKeyService
+}
+
+// NewTransactionHandler создаёт новый обработчик транзакций с лимитами переводов engine
+// и расписанием комиссий schedule.
+func NewTransactionHandler(wallets storage.WalletStorage, transactions storage.TransactionStorage, engine *limits.Engine, schedule *fees.Schedule) *TransactionHandler {
+	return &TransactionHandler{transactionService: service.NewTransactionService(wallets, transactions, engine, schedule)}
 }
 
 // NewWalletHandler создаёт новый обработчик кошельков.
-func NewWalletHandler(db *postgres.PgDB) *WalletHandler {
-	return &WalletHandler{walletService: service.NewWalletService(db)}
+func NewWalletHandler(wallets storage.WalletStorage, transactions storage.TransactionStorage) *WalletHandler {
+	return &WalletHandler{walletService: service.NewWalletService(wallets, transactions)}
+}
+
+// NewAPIKeyHandler создаёт новый обработчик ключей API.
+func NewAPIKeyHandler(keys storage.APIKeyStorage) *APIKeyHandler {
+	return &APIKeyHandler{keyService: service.NewAPIKeyService(keys)}
 }
 
 // ServeHTTP маршрутизирует запросы для TransactionHandler.
 func (th *TransactionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case r.Method == http.MethodPost && r.URL.Path == "/api/send":
-		errorMiddleware(th.sendMoney)(w, r)
+		errorMiddleware(requireScope(auth.ScopeTransferWrite, th.sendMoney))(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/send/quote":
+		errorMiddleware(requireScope(auth.ScopeWalletRead, th.quoteTransfer))(w, r)
 	case r.Method == http.MethodGet && r.URL.Path == "/api/transactions":
-		errorMiddleware(th.getLastTransactions)(w, r)
+		errorMiddleware(requireScope(auth.ScopeAdmin, th.getLastTransactions))(w, r)
 	default:
 		http.NotFound(w, r)
 	}
@@ -51,33 +125,124 @@ func (th *TransactionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 // ServeHTTP маршрутизирует запросы для WalletHandler.
 func (wh *WalletHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/wallet":
+		errorMiddleware(requireScope(auth.ScopeAdmin, wh.createWallet))(w, r)
+	case r.Method == http.MethodGet && walletRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeWalletRead, wh.getWalletDetails))(w, r)
+	case r.Method == http.MethodPatch && walletRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeWalletWrite, wh.updateWallet))(w, r)
+	case r.Method == http.MethodDelete && walletRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeWalletWrite, wh.closeWallet))(w, r)
 	case r.Method == http.MethodGet && walletBalanceRegex.MatchString(r.URL.Path):
-		errorMiddleware(wh.getWalletInfo)(w, r)
+		errorMiddleware(requireScope(auth.ScopeWalletRead, wh.getWalletInfo))(w, r)
+	case r.Method == http.MethodGet && walletTransactionsRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeWalletRead, wh.getWalletTransactions))(w, r)
+	case r.Method == http.MethodPut && adminWalletStatusRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeAdmin, wh.setWalletStatus))(w, r)
+	case r.Method == http.MethodGet && adminWalletStatusHistoryRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeAdmin, wh.getWalletStatusHistory))(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// ServeHTTP маршрутизирует запросы для APIKeyHandler. Все операции доступны только администратору.
+func (kh *APIKeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/admin/keys":
+		errorMiddleware(requireScope(auth.ScopeAdmin, kh.issueKey))(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/admin/keys":
+		errorMiddleware(requireScope(auth.ScopeAdmin, kh.listKeys))(w, r)
+	case r.Method == http.MethodDelete && adminKeyRegex.MatchString(r.URL.Path):
+		errorMiddleware(requireScope(auth.ScopeAdmin, kh.revokeKey))(w, r)
 	default:
 		http.NotFound(w, r)
 	}
 }
 
+// requireScope пропускает к обработчику только вызывающего с правом scope.
+func requireScope(scope auth.Scope, h HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if !auth.FromContext(r.Context()).HasScope(scope) {
+			return fmt.Errorf("%w: scope %s is required", domain.ErrForbidden, scope)
+		}
+		return h(w, r)
+	}
+}
+
+// authorizeWallet проверяет, что вызывающий владеет кошельком или является администратором.
+// Чужой и несуществующий кошельки неразличимы: оба дают domain.ErrForbidden.
+func authorizeWallet(r *http.Request, walletID uuid.UUID) error {
+	if !auth.FromContext(r.Context()).CanAccessWallet(walletID) {
+		return fmt.Errorf("%w: wallet %s", domain.ErrForbidden, walletID)
+	}
+	return nil
+}
+
 // sendMoney обрабатывает перевод средств между кошельками.
+// Необязательный заголовок Idempotency-Key делает запрос безопасным для повтора:
+// повтор возвращает исходный ответ, а повтор с другим телом — 409.
 func (th *TransactionHandler) sendMoney(w http.ResponseWriter, r *http.Request) error {
 	var req model.TransferMoneyRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return newHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse JSON: %v", err))
 	}
+	logging.AddAttrs(r.Context(), slog.String("from_wallet", req.From.String()), slog.String("to_wallet", req.To.String()))
+
+	// Списывать можно только с собственного кошелька; зачисление на любой кошелёк разрешено
+	if err := authorizeWallet(r, req.From); err != nil {
+		return err
+	}
+
+	req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return newHTTPError(http.StatusBadRequest, "Idempotency-Key header is too long")
+	}
 
 	if err := validation.ValidateAmount(req.Amount); err != nil {
 		return newHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	resp, err := th.transactionService.SendMoney(req)
+	resp, err := th.transactionService.SendMoney(r.Context(), req)
 	if err != nil {
-		return newHTTPError(resp.HttpStatus, err.Error())
+		return serviceError(resp.HttpStatus, err)
 	}
+	logging.AddAttrs(r.Context(), slog.String("transaction_id", resp.TransactionId.String()))
 
 	writeJSON(w, resp.HttpStatus, resp)
 	return nil
 }
 
+// quoteTransfer рассчитывает комиссию перевода по параметрам from, to и amount без перевода средств.
+func (th *TransactionHandler) quoteTransfer(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+	var req model.TransferMoneyRequest
+	var err error
+	if req.From, err = uuid.Parse(q.Get("from")); err != nil {
+		return newHTTPError(http.StatusBadRequest, "invalid query parameter 'from'")
+	}
+	if req.To, err = uuid.Parse(q.Get("to")); err != nil {
+		return newHTTPError(http.StatusBadRequest, "invalid query parameter 'to'")
+	}
+	if req.Amount, err = model.ParseMoney(q.Get("amount")); err != nil {
+		return newHTTPError(http.StatusBadRequest, "invalid query parameter 'amount'")
+	}
+	if err := authorizeWallet(r, req.From); err != nil {
+		return err
+	}
+	if err := validation.ValidateAmount(req.Amount); err != nil {
+		return newHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	quote, err := th.transactionService.QuoteTransfer(r.Context(), req)
+	if err != nil {
+		return serviceError(http.StatusBadRequest, err)
+	}
+
+	writeJSON(w, http.StatusOK, quote)
+	return nil
+}
+
 // getLastTransactions возвращает последние N транзакций.
 func (th *TransactionHandler) getLastTransactions(w http.ResponseWriter, r *http.Request) error {
 	countStr := r.URL.Query().Get("count")
@@ -86,9 +251,9 @@ func (th *TransactionHandler) getLastTransactions(w http.ResponseWriter, r *http
 		return newHTTPError(http.StatusBadRequest, "invalid query parameter 'count'")
 	}
 
-	transactions, err := th.transactionService.GetLastTransactions(count)
+	transactions, err := th.transactionService.GetLastTransactions(r.Context(), count)
 	if err != nil {
-		return newHTTPError(http.StatusInternalServerError, err.Error())
+		return serviceError(http.StatusInternalServerError, err)
 	}
 
 	writeJSON(w, http.StatusOK, transactions)
@@ -97,21 +262,317 @@ func (th *TransactionHandler) getLastTransactions(w http.ResponseWriter, r *http
 
 // getWalletInfo возвращает информацию о балансе кошелька.
 func (wh *WalletHandler) getWalletInfo(w http.ResponseWriter, r *http.Request) error {
-	matches := walletBalanceRegex.FindStringSubmatch(r.URL.Path)
+	walletID, err := walletIDFromPath(walletBalanceRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+	if err := authorizeWallet(r, walletID); err != nil {
+		return err
+	}
+
+	info, err := wh.walletService.GetWalletInfo(r.Context(), walletID)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, info)
+	return nil
+}
+
+// createWallet создаёт новый кошелёк.
+func (wh *WalletHandler) createWallet(w http.ResponseWriter, r *http.Request) error {
+	var req model.CreateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return newHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse JSON: %v", err))
+	}
+
+	if err := validation.ValidateCreateWallet(req); err != nil {
+		return newHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	wallet, err := wh.walletService.CreateWallet(r.Context(), req)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusCreated, wallet)
+	return nil
+}
+
+// getWalletDetails возвращает полную информацию о кошельке.
+func (wh *WalletHandler) getWalletDetails(w http.ResponseWriter, r *http.Request) error {
+	walletID, err := walletIDFromPath(walletRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+	if err := authorizeWallet(r, walletID); err != nil {
+		return err
+	}
+
+	wallet, err := wh.walletService.GetWalletDetails(r.Context(), walletID)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, wallet)
+	return nil
+}
+
+// updateWallet меняет статус кошелька (заморозка и закрытие).
+// Разморозить кошелёк может только администратор: заморозка могла быть наложена
+// по требованию комплаенса через PUT /api/admin/wallet/{id}/status, и владелец не должен её снимать.
+func (wh *WalletHandler) updateWallet(w http.ResponseWriter, r *http.Request) error {
+	walletID, err := walletIDFromPath(walletRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+	if err := authorizeWallet(r, walletID); err != nil {
+		return err
+	}
+
+	var req model.UpdateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return newHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse JSON: %v", err))
+	}
+	if err := validation.ValidateWalletStatusChange(req, false); err != nil {
+		return newHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.Status == model.WalletActive && !auth.FromContext(r.Context()).HasScope(auth.ScopeAdmin) {
+		return fmt.Errorf("%w: scope %s is required to unfreeze a wallet", domain.ErrForbidden, auth.ScopeAdmin)
+	}
+
+	wallet, err := wh.walletService.UpdateWalletStatus(r.Context(), walletID, req)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, wallet)
+	return nil
+}
+
+// closeWallet закрывает кошелёк с нулевым балансом.
+func (wh *WalletHandler) closeWallet(w http.ResponseWriter, r *http.Request) error {
+	walletID, err := walletIDFromPath(walletRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+	if err := authorizeWallet(r, walletID); err != nil {
+		return err
+	}
+
+	wallet, err := wh.walletService.UpdateWalletStatus(r.Context(), walletID, model.UpdateWalletRequest{Status: model.WalletClosed})
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, wallet)
+	return nil
+}
+
+// getWalletTransactions возвращает историю переводов кошелька с фильтрами и постраничной выборкой.
+// Параметры запроса: direction (incoming|outgoing|all), from_date и to_date (RFC 3339),
+// min_amount и max_amount, limit и cursor из nextCursor предыдущей страницы.
+func (wh *WalletHandler) getWalletTransactions(w http.ResponseWriter, r *http.Request) error {
+	walletID, err := walletIDFromPath(walletTransactionsRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+	if err := authorizeWallet(r, walletID); err != nil {
+		return err
+	}
+
+	filter, err := parseTransactionFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	filter.WalletId = walletID
+
+	page, err := wh.walletService.GetWalletTransactions(r.Context(), filter)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, page)
+	return nil
+}
+
+// parseTransactionFilter разбирает параметры фильтра истории переводов.
+func parseTransactionFilter(q url.Values) (model.TransactionFilter, error) {
+	filter := model.TransactionFilter{Direction: model.DirectionAll, Limit: defaultPageLimit}
+
+	if v := q.Get("direction"); v != "" {
+		filter.Direction = model.TransactionDirection(v)
+		if !filter.Direction.Valid() {
+			return filter, newHTTPError(http.StatusBadRequest, "invalid query parameter 'direction'")
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return filter, newHTTPError(http.StatusBadRequest, fmt.Sprintf("query parameter 'limit' must be between 1 and %d", maxPageLimit))
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.FromDate, err = parseTimeParam(q, "from_date"); err != nil {
+		return filter, err
+	}
+	if filter.ToDate, err = parseTimeParam(q, "to_date"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount, err = parseMoneyParam(q, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseMoneyParam(q, "max_amount"); err != nil {
+		return filter, err
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := model.ParseTransactionCursor(v)
+		if err != nil {
+			return filter, newHTTPError(http.StatusBadRequest, "invalid query parameter 'cursor'")
+		}
+		filter.Cursor = &cursor
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam разбирает необязательный параметр запроса в формате RFC 3339.
+// Время приводится к UTC, в котором хранится transfer_date.
+func parseTimeParam(q url.Values, name string) (*time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, newHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid query parameter '%s'", name))
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// parseMoneyParam разбирает необязательный денежный параметр запроса.
+func parseMoneyParam(q url.Values, name string) (*model.Money, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	m, err := model.ParseMoney(v)
+	if err != nil {
+		return nil, newHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid query parameter '%s'", name))
+	}
+	return &m, nil
+}
+
+// setWalletStatus — административная смена статуса кошелька (заморозка по требованию комплаенса).
+// В отличие от PATCH /api/wallet/{id} требует указать причину и инициатора.
+func (wh *WalletHandler) setWalletStatus(w http.ResponseWriter, r *http.Request) error {
+	walletID, err := walletIDFromPath(adminWalletStatusRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	var req model.UpdateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return newHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse JSON: %v", err))
+	}
+	if err := validation.ValidateWalletStatusChange(req, true); err != nil {
+		return newHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	wallet, err := wh.walletService.UpdateWalletStatus(r.Context(), walletID, req)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, wallet)
+	return nil
+}
+
+// getWalletStatusHistory возвращает историю смены статусов кошелька.
+func (wh *WalletHandler) getWalletStatusHistory(w http.ResponseWriter, r *http.Request) error {
+	walletID, err := walletIDFromPath(adminWalletStatusHistoryRegex, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	history, err := wh.walletService.GetWalletStatusHistory(r.Context(), walletID)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, history)
+	return nil
+}
+
+// walletIDFromPath извлекает UUID кошелька из пути по регулярному выражению re.
+func walletIDFromPath(re *regexp.Regexp, path string) (uuid.UUID, error) {
+	matches := re.FindStringSubmatch(path)
 	if len(matches) != 2 {
-		return newHTTPError(http.StatusBadRequest, "invalid wallet path")
+		return uuid.Nil, newHTTPError(http.StatusBadRequest, "invalid wallet path")
 	}
 
 	walletID, err := uuid.Parse(matches[1])
 	if err != nil {
-		return newHTTPError(http.StatusBadRequest, "invalid wallet ID format")
+		return uuid.Nil, newHTTPError(http.StatusBadRequest, "invalid wallet ID format")
+	}
+	return walletID, nil
+}
+
+// issueKey выпускает ключ API. Ключ возвращается только в этом ответе.
+func (kh *APIKeyHandler) issueKey(w http.ResponseWriter, r *http.Request) error {
+	var req model.IssueAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return newHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to parse JSON: %v", err))
+	}
+	if req.Role == "" {
+		req.Role = model.APIKeyClient
+	}
+	if err := validation.ValidateIssueAPIKey(req); err != nil {
+		return newHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	key, err := kh.keyService.Issue(r.Context(), req)
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+	logging.AddAttrs(r.Context(), slog.String("issued_api_key_id", key.Id.String()))
+
+	writeJSON(w, http.StatusCreated, key)
+	return nil
+}
+
+// listKeys возвращает все ключи API без самих ключей.
+func (kh *APIKeyHandler) listKeys(w http.ResponseWriter, r *http.Request) error {
+	keys, err := kh.keyService.List(r.Context())
+	if err != nil {
+		return serviceError(http.StatusInternalServerError, err)
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+	return nil
+}
+
+// revokeKey отзывает ключ API. Повторный отзыв возвращает тот же ответ.
+func (kh *APIKeyHandler) revokeKey(w http.ResponseWriter, r *http.Request) error {
+	matches := adminKeyRegex.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		return newHTTPError(http.StatusBadRequest, "invalid API key path")
+	}
+	keyID, err := uuid.Parse(matches[1])
+	if err != nil {
+		return newHTTPError(http.StatusBadRequest, "invalid API key ID format")
 	}
 
-	info, err := wh.walletService.GetWalletInfo(walletID)
+	key, err := kh.keyService.Revoke(r.Context(), keyID)
 	if err != nil {
-		return newHTTPError(http.StatusInternalServerError, err.Error())
+		return serviceError(http.StatusInternalServerError, err)
 	}
 
-	writeJSON(w, http.StatusOK, info)
+	writeJSON(w, http.StatusOK, key)
 	return nil
 }
@@ -0,0 +1,394 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/config"
+	"golang-server/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthorizeWallet(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	own, other := s.newWallet(t, "100"), s.newWallet(t, "100")
+	client := s.issueKey(t, model.APIKeyClient, own)
+	admin := s.issueKey(t, model.APIKeyAdmin)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		body   string
+		want   int
+	}{
+		{"own wallet", http.MethodGet, "/api/wallet/" + own.String(), client, "", http.StatusOK},
+		{"other wallet", http.MethodGet, "/api/wallet/" + other.String(), client, "", http.StatusForbidden},
+		{"other balance", http.MethodGet, "/api/wallet/" + other.String() + "/balance", client, "", http.StatusForbidden},
+		{"other history", http.MethodGet, "/api/wallet/" + other.String() + "/transactions", client, "", http.StatusForbidden},
+		{"freeze other", http.MethodPatch, "/api/wallet/" + other.String(), client, `{"status": "frozen"}`, http.StatusForbidden},
+		{"send from other", http.MethodPost, "/api/send", client, fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, other, own), http.StatusForbidden},
+		{"send to other", http.MethodPost, "/api/send", client, fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, own, other), http.StatusOK},
+		{"admin", http.MethodGet, "/api/wallet/" + other.String(), admin, "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := s.do(t, tt.method, tt.path, tt.key, tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (body %s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+		if tt.want == http.StatusForbidden && errorCode(t, rec) != "forbidden" {
+			t.Errorf("%s: error code %q, want forbidden", tt.name, errorCode(t, rec))
+		}
+	}
+
+	// Несуществующий кошелёк неотличим от чужого
+	if rec := s.do(t, http.MethodGet, "/api/wallet/"+uuid.NewString(), client, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("unknown wallet: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	s := newTestServer(t, &config.Config{Auth: config.AuthConfig{JWT: jwtConfig(t)}})
+	wallet, other := s.newWallet(t, "100"), s.newWallet(t, "0")
+	id := wallet.String()
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+		scope  auth.Scope
+	}{
+		{http.MethodGet, "/api/wallet/" + id, "", auth.ScopeWalletRead},
+		{http.MethodGet, "/api/wallet/" + id + "/balance", "", auth.ScopeWalletRead},
+		{http.MethodGet, "/api/wallet/" + id + "/transactions", "", auth.ScopeWalletRead},
+		{http.MethodGet, "/api/send/quote?from=" + id + "&to=" + other.String() + "&amount=1", "", auth.ScopeWalletRead},
+		{http.MethodPatch, "/api/wallet/" + id, `{"status": "frozen"}`, auth.ScopeWalletWrite},
+		{http.MethodDelete, "/api/wallet/" + other.String(), "", auth.ScopeWalletWrite},
+		{http.MethodPost, "/api/send", fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, wallet, other), auth.ScopeTransferWrite},
+		{http.MethodPost, "/api/wallet", `{}`, auth.ScopeAdmin},
+		{http.MethodGet, "/api/transactions?count=1", "", auth.ScopeAdmin},
+		{http.MethodPut, "/api/admin/wallet/" + id + "/status", `{"status": "active", "reason": "check"}`, auth.ScopeAdmin},
+		{http.MethodGet, "/api/admin/wallet/" + id + "/status-history", "", auth.ScopeAdmin},
+		{http.MethodGet, "/api/admin/keys", "", auth.ScopeAdmin},
+		{http.MethodPost, "/api/admin/keys", `{"name": "shop", "role": "admin"}`, auth.ScopeAdmin},
+		{http.MethodDelete, "/api/admin/keys/" + uuid.NewString(), "", auth.ScopeAdmin},
+	}
+	wallets := []string{id, other.String()}
+	for _, rt := range routes {
+		// Все права, кроме нужного маршруту; admin даёт доступ ко всему, поэтому в остальных не выдаётся
+		var scopes []string
+		for _, scope := range auth.ClientScopes {
+			if scope != rt.scope {
+				scopes = append(scopes, string(scope))
+			}
+		}
+		without := signJWT(t, map[string]any{"sub": "alice", "scp": scopes, "wallets": wallets})
+		rec := s.do(t, rt.method, rt.path, without, rt.body)
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "scope "+string(rt.scope)) {
+			t.Errorf("%s %s without %s: status %d, body %s", rt.method, rt.path, rt.scope, rec.Code, rec.Body)
+		}
+
+		with := signJWT(t, map[string]any{"sub": "alice", "scope": string(rt.scope), "wallets": wallets})
+		if rec := s.do(t, rt.method, rt.path, with, rt.body); rec.Code == http.StatusForbidden || rec.Code >= http.StatusInternalServerError {
+			t.Errorf("%s %s with %s: status %d, body %s", rt.method, rt.path, rt.scope, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestStatusChangeActor(t *testing.T) {
+	s := newTestServer(t, &config.Config{Auth: config.AuthConfig{JWT: jwtConfig(t)}})
+	wallet := s.newWallet(t, "0")
+	token := signJWT(t, map[string]any{"sub": "ops", "scope": "admin"})
+
+	// Инициатор берётся из токена, а не из тела запроса
+	body := `{"status": "frozen", "reason": "AML hold", "actor": "someone-else"}`
+	if rec := s.do(t, http.MethodPut, "/api/admin/wallet/"+wallet.String()+"/status", token, body); rec.Code != http.StatusOK {
+		t.Fatalf("set status: status %d, body %s", rec.Code, rec.Body)
+	}
+	rec := s.do(t, http.MethodGet, "/api/admin/wallet/"+wallet.String()+"/status-history", token, "")
+	var history []model.WalletStatusChange
+	decode(t, rec, &history)
+	if len(history) != 1 || history[0].Actor != "ops" {
+		t.Errorf("status history = %+v, want one change by ops", history)
+	}
+
+	if rec := s.do(t, http.MethodPut, "/api/admin/wallet/"+wallet.String()+"/status", token, `{"status": "active"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("admin status change without reason: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestOwnerStatusChange(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	wallet, held := s.newWallet(t, "0"), s.newWallet(t, "0")
+	client := s.issueKey(t, model.APIKeyClient, wallet, held)
+	admin := s.issueKey(t, model.APIKeyAdmin)
+	patch := func(key string, id uuid.UUID, status model.WalletStatus) *httptest.ResponseRecorder {
+		return s.do(t, http.MethodPatch, "/api/wallet/"+id.String(), key, fmt.Sprintf(`{"status": %q}`, status))
+	}
+
+	// Владелец может заморозить свой кошелёк, но не разморозить его
+	if rec := patch(client, wallet, model.WalletFrozen); rec.Code != http.StatusOK {
+		t.Fatalf("owner freeze: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := patch(client, wallet, model.WalletActive); rec.Code != http.StatusForbidden || errorCode(t, rec) != "forbidden" {
+		t.Errorf("owner unfreeze: status %d, body %s; want 403 forbidden", rec.Code, rec.Body)
+	}
+	if rec := patch(admin, wallet, model.WalletActive); rec.Code != http.StatusOK {
+		t.Errorf("admin unfreeze: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	// Заморозку администратора владелец снять не может, но может закрыть кошелёк
+	body := `{"status": "frozen", "reason": "AML hold"}`
+	if rec := s.do(t, http.MethodPut, "/api/admin/wallet/"+held.String()+"/status", admin, body); rec.Code != http.StatusOK {
+		t.Fatalf("admin freeze: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := patch(client, held, model.WalletActive); rec.Code != http.StatusForbidden {
+		t.Errorf("owner unfreeze of an admin hold: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if w, err := s.store.GetWallet(t.Context(), held); err != nil || w.Status != model.WalletFrozen {
+		t.Errorf("wallet after owner unfreeze = %+v, %v; want frozen", w, err)
+	}
+	if rec := patch(client, held, model.WalletClosed); rec.Code != http.StatusOK {
+		t.Errorf("owner close of a frozen wallet: status %d, body %s", rec.Code, rec.Body)
+	}
+}
+
+func TestQuoteTransfer(t *testing.T) {
+	collection := uuid.New()
+	cfg := &config.Config{Fees: config.FeesConfig{
+		CollectionWallet:  collection.String(),
+		FeeScheduleConfig: config.FeeScheduleConfig{Flat: "0.5", Percent: "1"},
+	}}
+	s := newTestServer(t, cfg)
+	if err := s.store.CreateWallet(t.Context(), &model.Wallet{Id: collection, Status: model.WalletActive}); err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	from, to, other := s.newWallet(t, "200"), s.newWallet(t, "0"), s.newWallet(t, "0")
+	key := s.issueKey(t, model.APIKeyClient, from)
+
+	rec := s.do(t, http.MethodGet, fmt.Sprintf("/api/send/quote?from=%s&to=%s&amount=100", from, to), key, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("quote: status %d, body %s", rec.Code, rec.Body)
+	}
+	var quote model.TransferQuoteResponse
+	decode(t, rec, &quote)
+	if quote.From != from || quote.To != to || quote.Amount.String() != "100" || quote.Fee.String() != "1.5" || quote.Total.String() != "101.5" {
+		t.Errorf("quote = %+v, want fee 1.5 and total 101.5", quote)
+	}
+
+	// Перевод удерживает ту же комиссию, что и расчёт
+	rec = s.do(t, http.MethodPost, "/api/send", key, fmt.Sprintf(`{"from": %q, "to": %q, "amount": "100"}`, from, to))
+	var sent model.TransferMoneyResponse
+	decode(t, rec, &sent)
+	if rec.Code != http.StatusOK || sent.Fee.Cmp(quote.Fee) != 0 {
+		t.Errorf("transfer: status %d, fee %s, want %s", rec.Code, sent.Fee, quote.Fee)
+	}
+	if balance, _ := s.store.GetBalance(t.Context(), from); balance.String() != "98.5" {
+		t.Errorf("sender balance = %s, want 98.5", balance)
+	}
+	if balance, _ := s.store.GetBalance(t.Context(), collection); balance.String() != "1.5" {
+		t.Errorf("fee wallet balance = %s, want 1.5", balance)
+	}
+
+	failures := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"other sender", fmt.Sprintf("from=%s&to=%s&amount=1", other, to), http.StatusForbidden},
+		{"same wallet", fmt.Sprintf("from=%s&to=%s&amount=1", from, from), http.StatusBadRequest},
+		{"invalid amount", fmt.Sprintf("from=%s&to=%s&amount=abc", from, to), http.StatusBadRequest},
+		{"negative amount", fmt.Sprintf("from=%s&to=%s&amount=-1", from, to), http.StatusBadRequest},
+		{"missing recipient", fmt.Sprintf("from=%s&amount=1", from), http.StatusBadRequest},
+	}
+	for _, tt := range failures {
+		if rec := s.do(t, http.MethodGet, "/api/send/quote?"+tt.query, key, ""); rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (body %s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+func TestParseTransactionFilter(t *testing.T) {
+	cursor := model.TransactionCursor{TransferDate: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), Id: uuid.New()}
+	q := url.Values{
+		"direction":  {"outgoing"},
+		"limit":      {"10"},
+		"from_date":  {"2026-03-01T03:00:00+03:00"},
+		"to_date":    {"2026-03-02T00:00:00Z"},
+		"min_amount": {"1.5"},
+		"max_amount": {"100"},
+		"cursor":     {cursor.Encode()},
+	}
+	f, err := parseTransactionFilter(q)
+	if err != nil {
+		t.Fatalf("parseTransactionFilter: %v", err)
+	}
+	if f.Direction != model.DirectionOutgoing || f.Limit != 10 || f.MinAmount.String() != "1.5" || f.MaxAmount.String() != "100" {
+		t.Errorf("filter = %+v", f)
+	}
+	// Время с часовым поясом приводится к UTC
+	if !f.FromDate.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || f.FromDate.Location() != time.UTC || !f.ToDate.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("dates = %s .. %s", f.FromDate, f.ToDate)
+	}
+	if f.Cursor == nil || !f.Cursor.TransferDate.Equal(cursor.TransferDate) || f.Cursor.Id != cursor.Id {
+		t.Errorf("cursor = %+v, want %+v", f.Cursor, cursor)
+	}
+
+	f, err = parseTransactionFilter(url.Values{})
+	if err != nil || f.Direction != model.DirectionAll || f.Limit != defaultPageLimit || f.Cursor != nil || f.FromDate != nil {
+		t.Errorf("default filter = %+v, %v", f, err)
+	}
+
+	invalid := []url.Values{
+		{"direction": {"sideways"}},
+		{"limit": {"0"}},
+		{"limit": {"501"}},
+		{"limit": {"ten"}},
+		{"from_date": {"2026-03-01"}},
+		{"to_date": {"yesterday"}},
+		{"min_amount": {"1e3"}},
+		{"cursor": {"not-a-cursor"}},
+		{"cursor": {base64.RawURLEncoding.EncodeToString([]byte("2026-03-01T00:00:00Z|bad-id"))}},
+	}
+	for _, q := range invalid {
+		if _, err := parseTransactionFilter(q); err == nil {
+			t.Errorf("parseTransactionFilter(%v) succeeded, want error", q)
+		} else if httpErr := toHTTPError(err); httpErr.Status != http.StatusBadRequest {
+			t.Errorf("parseTransactionFilter(%v): status %d, want %d", q, httpErr.Status, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestWalletTransactionsPagination(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	a, b := s.newWallet(t, "100"), s.newWallet(t, "100")
+	key := s.issueKey(t, model.APIKeyAdmin)
+
+	var sent []uuid.UUID
+	for i := range 5 {
+		from, to := a, b
+		if i%2 == 1 {
+			from, to = b, a
+		}
+		rec := s.do(t, http.MethodPost, "/api/send", key, fmt.Sprintf(`{"from": %q, "to": %q, "amount": "%d"}`, from, to, i+1))
+		var resp model.TransferMoneyResponse
+		decode(t, rec, &resp)
+		sent = append(sent, resp.TransactionId)
+	}
+
+	// Страницы по две записи от новых к старым; последняя страница без nextCursor
+	var got []uuid.UUID
+	path := "/api/wallet/" + a.String() + "/transactions?limit=2"
+	for cursor, pages := "", 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not terminate")
+		}
+		p := path
+		if cursor != "" {
+			p += "&cursor=" + cursor
+		}
+		rec := s.do(t, http.MethodGet, p, key, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("page %d: status %d, body %s", pages, rec.Code, rec.Body)
+		}
+		var page model.TransactionPageResponse
+		decode(t, rec, &page)
+		for _, tx := range page.Transactions {
+			got = append(got, tx.TransactionId)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if !slices.Equal(got, []uuid.UUID{sent[4], sent[3], sent[2], sent[1], sent[0]}) {
+		t.Errorf("paged history = %v, want transfers %v newest first", got, sent)
+	}
+
+	rec := s.do(t, http.MethodGet, "/api/wallet/"+a.String()+"/transactions?direction=incoming", key, "")
+	var incoming model.TransactionPageResponse
+	decode(t, rec, &incoming)
+	if len(incoming.Transactions) != 2 || incoming.Transactions[0].TransactionId != sent[3] {
+		t.Errorf("incoming transfers = %+v, want transfers 4 and 2", incoming.Transactions)
+	}
+	if rec := s.do(t, http.MethodGet, path+"&cursor=bogus", key, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid cursor: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestSendMoneyIdempotencyKey(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	from, to := s.newWallet(t, "100"), s.newWallet(t, "0")
+	key := s.issueKey(t, model.APIKeyClient, from)
+	body := fmt.Sprintf(`{"from": %q, "to": %q, "amount": "10"}`, from, to)
+
+	send := func(body, idempotencyKey string) (*httptest.ResponseRecorder, model.TransferMoneyResponse) {
+		rec := s.do(t, http.MethodPost, "/api/send", key, body, "Idempotency-Key", idempotencyKey)
+		var resp model.TransferMoneyResponse
+		if rec.Code == http.StatusOK {
+			decode(t, rec, &resp)
+		}
+		return rec, resp
+	}
+
+	rec, first := send(body, "order-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first request: status %d, body %s", rec.Code, rec.Body)
+	}
+	rec, replay := send(body, "order-1")
+	if rec.Code != http.StatusOK || replay.TransactionId != first.TransactionId {
+		t.Errorf("replay: status %d, transaction %s, want %s", rec.Code, replay.TransactionId, first.TransactionId)
+	}
+	if balance, _ := s.store.GetBalance(t.Context(), from); balance.String() != "90" {
+		t.Errorf("sender balance after replay = %s, want 90", balance)
+	}
+
+	changed := strings.Replace(body, `"10"`, `"20"`, 1)
+	if rec, _ := send(changed, "order-1"); rec.Code != http.StatusConflict || errorCode(t, rec) != "idempotency_key_conflict" {
+		t.Errorf("changed body: status %d, body %s; want 409", rec.Code, rec.Body)
+	}
+	if rec, _ := send(body, strings.Repeat("k", maxIdempotencyKeyLength+1)); rec.Code != http.StatusBadRequest {
+		t.Errorf("too long key: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec, next := send(body, strings.Repeat("k", maxIdempotencyKeyLength)); rec.Code != http.StatusOK || next.TransactionId == first.TransactionId {
+		t.Errorf("new key: status %d, transaction %s, want a new transfer", rec.Code, next.TransactionId)
+	}
+}
+
+func TestDomainErrorStatus(t *testing.T) {
+	s := newTestServer(t, &config.Config{Auth: config.AuthConfig{Disabled: true}})
+	from, to, frozen := s.newWallet(t, "10"), s.newWallet(t, "0"), s.newWallet(t, "10")
+	change := model.WalletStatusChange{NewStatus: model.WalletFrozen, Reason: "test", Actor: "test"}
+	if _, err := s.store.UpdateWalletStatus(t.Context(), frozen, change); err != nil {
+		t.Fatalf("UpdateWalletStatus: %v", err)
+	}
+
+	// Хранилище оборачивает доменные ошибки, errorMiddleware находит их через errors.As
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"unknown wallet", http.MethodGet, "/api/wallet/" + uuid.NewString(), "", http.StatusNotFound, "wallet_not_found"},
+		{"unknown recipient", http.MethodPost, "/api/send", fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, from, uuid.New()), http.StatusNotFound, "wallet_not_found"},
+		{"insufficient funds", http.MethodPost, "/api/send", fmt.Sprintf(`{"from": %q, "to": %q, "amount": "11"}`, from, to), http.StatusUnprocessableEntity, "insufficient_funds"},
+		{"frozen sender", http.MethodPost, "/api/send", fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, frozen, to), http.StatusLocked, "wallet_frozen"},
+		{"frozen recipient", http.MethodPost, "/api/send", fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, from, frozen), http.StatusLocked, "wallet_frozen"},
+	}
+	for _, tt := range tests {
+		rec := s.do(t, tt.method, tt.path, "", tt.body)
+		if rec.Code != tt.status || errorCode(t, rec) != tt.code {
+			t.Errorf("%s: status %d, body %s; want %d %s", tt.name, rec.Code, rec.Body, tt.status, tt.code)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// readinessCheckTimeout ограничивает время одной проверки зависимости в /readyz.
+const readinessCheckTimeout = 2 * time.Second
+
+// DependencyCheck — проверка одной зависимости сервера для /readyz.
+// Check возвращает подробности для ответа и ошибку, если зависимость не готова.
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) (details any, err error)
+}
+
+// BuildInfo — сведения о сборке для /api/status.
+type BuildInfo struct {
+	Version string
+	Commit  string
+}
+
+// HealthHandler обслуживает пробы балансировщика и оркестратора:
+//   - GET /healthz — процесс жив и обрабатывает запросы;
+//   - GET /readyz — зависимости доступны и сервер принимает трафик;
+//   - GET /api/status — версия сборки, коммит и время работы.
+//
+// При завершении работы сервер переводит его в режим draining: /readyz начинает
+// отвечать 503, и балансировщик перестаёт направлять новые запросы, пока текущие дорабатывают.
+type HealthHandler struct {
+	draining  atomic.Bool
+	checks    []DependencyCheck
+	build     BuildInfo
+	startedAt time.Time
+}
+
+// checkResult — результат одной проверки в ответе /readyz.
+type checkResult struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// readinessResponse — тело ответа /healthz и /readyz.
+type readinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// statusResponse — тело ответа /api/status.
+type statusResponse struct {
+	Version       string    `json:"version"`
+	Commit        string    `json:"commit"`
+	StartedAt     time.Time `json:"started_at"`
+	Uptime        string    `json:"uptime"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+}
+
+// NewHealthHandler создаёт обработчик проб в состоянии готовности.
+// Параметры:
+//   - build: версия и коммит сборки.
+//   - checks: проверки зависимостей, выполняемые на каждый запрос /readyz.
+func NewHealthHandler(build BuildInfo, checks ...DependencyCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:    checks,
+		build:     build,
+		startedAt: time.Now(),
+	}
+}
+
+// SetDraining переводит сервер в режим завершения: /readyz отвечает 503.
+func (hh *HealthHandler) SetDraining() {
+	hh.draining.Store(true)
+}
+
+// ServeHTTP маршрутизирует запросы для HealthHandler.
+func (hh *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/healthz":
+		writeJSON(w, http.StatusOK, readinessResponse{Status: "ok"})
+	case r.Method == http.MethodGet && r.URL.Path == "/readyz":
+		hh.readiness(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/status":
+		hh.status(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// readiness выполняет проверки зависимостей и отвечает 200, если все они прошли,
+// и 503, если хотя бы одна не прошла или сервер завершает работу.
+func (hh *HealthHandler) readiness(w http.ResponseWriter, r *http.Request) {
+	if hh.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, readinessResponse{Status: "draining"})
+		return
+	}
+
+	response := readinessResponse{Status: "ready", Checks: make(map[string]checkResult, len(hh.checks))}
+	status := http.StatusOK
+	for _, c := range hh.checks {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+		details, err := c.Check(ctx)
+		cancel()
+
+		result := checkResult{Status: "ok", Details: details}
+		if err != nil {
+			result.Status = "fail"
+			result.Error = err.Error()
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+		response.Checks[c.Name] = result
+	}
+
+	writeJSON(w, status, response)
+}
+
+// status возвращает сведения о сборке и время работы процесса.
+func (hh *HealthHandler) status(w http.ResponseWriter, _ *http.Request) {
+	uptime := time.Since(hh.startedAt)
+	writeJSON(w, http.StatusOK, statusResponse{
+		Version:       hh.build.Version,
+		Commit:        hh.build.Commit,
+		StartedAt:     hh.startedAt.UTC(),
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+	})
+}
+
+// PingCheck проверяет доступность базы данных запросом Ping.
+func PingCheck(db *sql.DB) DependencyCheck {
+	return DependencyCheck{
+		Name: "database",
+		Check: func(ctx context.Context) (any, error) {
+			start := time.Now()
+			if err := db.PingContext(ctx); err != nil {
+				return nil, err
+			}
+			return map[string]string{"latency": time.Since(start).String()}, nil
+		},
+	}
+}
+
+// poolStats — состояние пула соединений в ответе /readyz.
+type poolStats struct {
+	MaxOpen      int      `json:"max_open"`
+	Open         int      `json:"open"`
+	InUse        int      `json:"in_use"`
+	Idle         int      `json:"idle"`
+	WaitCount    int64    `json:"wait_count"`
+	WaitDuration string   `json:"wait_duration"`
+	Saturation   *float64 `json:"saturation,omitempty"`
+}
+
+// PoolCheck сообщает заполненность пула соединений: долю занятых соединений от MaxOpen
+// и число ожиданий свободного соединения. Проверка никогда не проваливается:
+// при пиковой нагрузке пул заполнен у всех реплик сразу, и отказ готовности
+// вывел бы из балансировки их все одновременно.
+func PoolCheck(db *sql.DB) DependencyCheck {
+	return DependencyCheck{
+		Name: "pool",
+		Check: func(context.Context) (any, error) {
+			s := db.Stats()
+			stats := poolStats{
+				MaxOpen:      s.MaxOpenConnections,
+				Open:         s.OpenConnections,
+				InUse:        s.InUse,
+				Idle:         s.Idle,
+				WaitCount:    s.WaitCount,
+				WaitDuration: s.WaitDuration.String(),
+			}
+			// Без ограничения размера пула заполненность не определена
+			if s.MaxOpenConnections > 0 {
+				saturation := float64(s.InUse) / float64(s.MaxOpenConnections)
+				stats.Saturation = &saturation
+			}
+			return stats, nil
+		},
+	}
+}
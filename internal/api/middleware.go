@@ -1,16 +1,36 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/config"
+	"golang-server/internal/domain"
+	"golang-server/internal/logging"
+	"golang-server/internal/metrics"
+	"golang-server/internal/ratelimit"
+	"golang-server/internal/service"
+	"golang-server/internal/storage"
+	"golang-server/internal/tracing"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // HTTPError представляет ошибку HTTP с кодом и сообщением.
+// Code заполняется для доменных ошибок и позволяет клиентам ветвиться по причине отказа.
 type HTTPError struct {
 	Status  int    `json:"-"`
+	Code    string `json:"code,omitempty"`
 	Message string `json:"error"`
 }
 
@@ -23,24 +43,243 @@ func newHTTPError(status int, msg string) *HTTPError {
 	return &HTTPError{Status: status, Message: msg}
 }
 
-// LoggingMiddleware логирует начало и конец обработки каждого запроса с временем выполнения.
+// StatusClientClosedRequest — нестандартный статус 499 (nginx): клиент закрыл соединение
+// до получения ответа. Пишется в лог и метрики; сам клиент ответ уже не получит.
+const StatusClientClosedRequest = 499
+
+// domainErrorStatus сопоставляет доменные ошибки с HTTP-статусами.
+var domainErrorStatus = map[*domain.Error]int{
+	domain.ErrWalletNotFound:          http.StatusNotFound,
+	domain.ErrSameWallet:              http.StatusBadRequest,
+	domain.ErrInsufficientFunds:       http.StatusUnprocessableEntity,
+	domain.ErrWalletFrozen:            http.StatusLocked,
+	domain.ErrIdempotencyConflict:     http.StatusConflict,
+	domain.ErrWalletClosed:            http.StatusGone,
+	domain.ErrWalletNotEmpty:          http.StatusConflict,
+	domain.ErrInvalidStatusTransition: http.StatusConflict,
+	domain.ErrUnauthorized:            http.StatusUnauthorized,
+	domain.ErrForbidden:               http.StatusForbidden,
+	domain.ErrAPIKeyNotFound:          http.StatusNotFound,
+	domain.ErrTransferLimitExceeded:   http.StatusUnprocessableEntity,
+	domain.ErrDailyLimitExceeded:      http.StatusUnprocessableEntity,
+	domain.ErrMonthlyLimitExceeded:    http.StatusUnprocessableEntity,
+	domain.ErrHourlyCountExceeded:     http.StatusUnprocessableEntity,
+}
+
+// serviceError возвращает доменные ошибки и ошибки отмены контекста как есть,
+// чтобы их преобразовал errorMiddleware, а прочие ошибки оборачивает в HTTPError с указанным статусом.
+func serviceError(status int, err error) error {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return newHTTPError(status, err.Error())
+}
+
+// toHTTPError преобразует ошибку обработчика в HTTPError:
+// доменные ошибки — по таблице domainErrorStatus, отмену запроса — в 499,
+// истечение срока — в 504, остальные неизвестные ошибки — в 500.
+func toHTTPError(err error) *HTTPError {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status, ok := domainErrorStatus[domainErr]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		return &HTTPError{Status: status, Code: domainErr.Code, Message: err.Error()}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return &HTTPError{Status: StatusClientClosedRequest, Code: "request_canceled", Message: "request canceled"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &HTTPError{Status: http.StatusGatewayTimeout, Code: "request_timeout", Message: "request timed out"}
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	// Неизвестная ошибка
+	return &HTTPError{Status: http.StatusInternalServerError, Message: "internal server error"}
+}
+
+// unloggedPaths — пути проб, которые опрашиваются каждые несколько секунд и не пишутся в лог.
+var unloggedPaths = map[string]bool{
+	"/healthz":    true,
+	"/readyz":     true,
+	"/api/status": true,
+	"/metrics":    true,
+}
+
+// RequestIDHeader — заголовок с идентификатором запроса.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength — максимальная длина идентификатора запроса, принимаемого от клиента.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware берёт идентификатор запроса из заголовка X-Request-ID
+// или генерирует новый, если заголовка нет или он некорректен.
+// Идентификатор кладётся в контекст запроса для лога и возвращается клиенту в том же заголовке.
+// Если запрос трассируется, к записям лога добавляются также trace_id и span_id.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := logging.WithRequestID(r.Context(), id)
+		if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+			logging.AddAttrs(ctx, slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+		}
+		tracing.SpanFromContext(ctx).SetAttributes(slog.String("http.request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID проверяет, что идентификатор непустой, не длиннее maxRequestIDLength
+// и состоит из печатных ASCII-символов без пробелов.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoggingMiddleware пишет по одной записи на запрос после его обработки: метод, маршрут,
+// статус и время выполнения вместе с атрибутами, которые обработчики добавили через
+// logging.AddAttrs (идентификатор запроса, кошельки перевода, ошибка).
+// Уровень записи зависит от статуса: 5xx — error, 4xx — warn, остальные — info.
+// Запросы проб (unloggedPaths) не логируются.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unloggedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		start := time.Now()
-		log.Printf("[START] %s %s", r.Method, r.URL.Path)
+		rec := &statusRecorder{ResponseWriter: w}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rec, r)
+
+		status := rec.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		slog.LogAttrs(r.Context(), level, "request completed",
+			slog.String("method", r.Method),
+			slog.String("route", routeLabel(r.URL.Path)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+	})
+}
+
+var (
+	httpRequestsTotal = metrics.Default.NewCounterVec(
+		"http_requests_total", "Number of HTTP requests by route and status.", "method", "route", "status")
+	httpRequestDuration = metrics.Default.NewHistogramVec(
+		"http_request_duration_seconds", "HTTP request latency by route and status.", metrics.DefaultBuckets, "method", "route", "status")
+)
+
+// MetricsMiddleware учитывает число и длительность запросов в разрезе метода, маршрута и статуса.
+// Регистрируется первым, чтобы видеть и ответы RecoveryMiddleware.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
 
-		log.Printf("[END] %s %s in %v", r.Method, r.URL.Path, time.Since(start))
+		next.ServeHTTP(rec, r)
+
+		status := strconv.Itoa(rec.Status())
+		route := routeLabel(r.URL.Path)
+		httpRequestsTotal.Inc(r.Method, route, status)
+		httpRequestDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
 	})
 }
 
+// tracingMiddleware начинает span входящего запроса, продолжая трассу вызывающего
+// из заголовка traceparent. Ответы 5xx отмечаются в span'е как ошибка.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeLabel(r.URL.Path)
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, r.Method+" "+route, tracing.KindServer,
+			slog.String("http.request.method", r.Method),
+			slog.String("http.route", route),
+			slog.String("url.path", r.URL.Path),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.Status()
+		span.SetAttributes(slog.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("HTTP %d", status))
+		}
+	})
+}
+
+// statusRecorder запоминает статус ответа для middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Status возвращает записанный статус; 200, если обработчик ничего не записал.
+func (r *statusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
 // RecoveryMiddleware перехватывает паники и возвращает статус 500.
+// Паника и стек попадают в запись LoggingMiddleware, поэтому он регистрируется раньше.
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				log.Printf("[PANIC] %v", rec)
+				logging.AddAttrs(r.Context(),
+					slog.String("panic", fmt.Sprint(rec)),
+					slog.String("stack", string(debug.Stack())),
+				)
 				writeJSON(w, http.StatusInternalServerError, HTTPError{Message: "internal server error"})
 			}
 		}()
@@ -48,23 +287,274 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// publicPaths — пути, доступные без ключа API: пробы балансировщика и сбор метрик.
+var publicPaths = map[string]bool{
+	"/healthz":    true,
+	"/readyz":     true,
+	"/api/status": true,
+	"/metrics":    true,
+}
+
+// APIKeyHeader — альтернативный заголовок с ключом API для клиентов,
+// которые не могут передать Authorization.
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware проверяет учётные данные из заголовка Authorization: Bearer <ключ или JWT>
+// или X-API-Key и кладёт вызывающего в контекст запроса (см. auth.FromContext).
+// Bearer-токен из трёх частей через точку проверяется как JWT, если задан tokens,
+// остальные — как ключи API. Запрос без учётных данных, с неизвестным или отозванным ключом
+// или с недействительным токеном получает 401. Права (scopes) и доступ к кошелькам
+// проверяют обработчики. Пути publicPaths доступны без учётных данных.
+func AuthMiddleware(keys storage.APIKeyStorage, tokens *auth.JWTVerifier) func(http.Handler) http.Handler {
+	keyService := service.NewAPIKeyService(keys)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errorMiddleware(func(w http.ResponseWriter, r *http.Request) error {
+				key := apiKeyFromRequest(r)
+				if key == "" {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					return domain.ErrUnauthorized
+				}
+
+				var principal *auth.Principal
+				var err error
+				if tokens != nil && r.Header.Get(APIKeyHeader) == "" && auth.IsJWT(key) {
+					principal, err = tokens.Verify(key)
+					if err != nil {
+						err = fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+					}
+				} else {
+					principal, err = keyService.Authenticate(r.Context(), key)
+				}
+				if errors.Is(err, domain.ErrUnauthorized) {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				}
+				if err != nil {
+					return serviceError(http.StatusInternalServerError, err)
+				}
+
+				logging.AddAttrs(r.Context(), slog.String("subject", principal.Subject))
+				tracing.SpanFromContext(r.Context()).SetAttributes(slog.String("enduser.id", principal.Subject))
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				return nil
+			})(w, r)
+		})
+	}
+}
+
+// apiKeyFromRequest возвращает ключ API из заголовка Authorization или X-API-Key.
+func apiKeyFromRequest(r *http.Request) string {
+	if scheme, key, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(key)
+	}
+	return r.Header.Get(APIKeyHeader)
+}
+
+// NoAuthMiddleware заменяет AuthMiddleware, когда аутентификация отключена в конфигурации:
+// каждый запрос выполняется с правами администратора. Только для разработки и тестов.
+func NoAuthMiddleware(next http.Handler) http.Handler {
+	anonymous := &auth.Principal{Subject: "anonymous", Name: "anonymous", Scopes: auth.AdminScopes}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), anonymous)))
+	})
+}
+
+// maxBufferedBodySize — максимальный размер тела, которое middleware читает в память целиком.
+const maxBufferedBodySize = 1 << 20
+
+// readBody читает тело запроса целиком и подменяет r.Body копией, чтобы обработчик прочитал его снова.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBufferedBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, newHTTPError(http.StatusRequestEntityTooLarge, "request body is too large")
+	}
+	if err != nil {
+		return nil, newHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// signatureErrorCodes сопоставляет ошибки проверки подписи с кодами ответа 401.
+var signatureErrorCodes = map[error]string{
+	auth.ErrSignatureMissing:  "signature_missing",
+	auth.ErrSignatureInvalid:  "signature_invalid",
+	auth.ErrSignatureExpired:  "signature_expired",
+	auth.ErrSignatureReplayed: "signature_replayed",
+}
+
+// SignatureMiddleware проверяет подпись HMAC-SHA256 запросов к путям cfg.Paths:
+// метод, путь со строкой запроса, время, nonce и тело, подписанные общим секретом клиента.
+// Запрос вне окна cfg.ClockSkew или с уже использованным nonce отклоняется,
+// как и неподписанный запрос. Запросы к остальным путям проходят без проверки.
+func SignatureMiddleware(cfg config.SigningConfig) (func(http.Handler) http.Handler, error) {
+	verifier, err := auth.NewVerifier(cfg.Clients, time.Duration(cfg.ClockSkew))
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool, len(cfg.Paths))
+	for _, p := range cfg.Paths {
+		paths[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !paths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errorMiddleware(func(w http.ResponseWriter, r *http.Request) error {
+				body, err := readBody(w, r)
+				if err != nil {
+					return err
+				}
+
+				client := r.Header.Get(auth.SignatureClientHeader)
+				err = verifier.Verify(client, r.Header.Get(auth.SignatureHeader), auth.SignedRequest{
+					Method:    r.Method,
+					Path:      r.URL.RequestURI(),
+					Timestamp: r.Header.Get(auth.SignatureTimestampHeader),
+					Nonce:     r.Header.Get(auth.SignatureNonceHeader),
+					Body:      body,
+				})
+				if err != nil {
+					return &HTTPError{Status: http.StatusUnauthorized, Code: signatureErrorCodes[err], Message: err.Error()}
+				}
+
+				logging.AddAttrs(r.Context(), slog.String("signature_client", client))
+				next.ServeHTTP(w, r)
+				return nil
+			})(w, r)
+		})
+	}, nil
+}
+
+var rateLimitedTotal = metrics.Default.NewCounterVec(
+	"http_rate_limited_total", "Number of requests rejected by the rate limiter.", "limit")
+
+// RateLimitMiddleware ограничивает частоту запросов корзинами токенов из store:
+// общую для всех запросов клиента (субъекта из auth.FromContext) и для переводов
+// с одного кошелька (поле from тела POST /api/send, если кошелёк доступен вызывающему). Каждый ответ содержит заголовки
+// RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset самой исчерпанной корзины,
+// а отказ — статус 429 и Retry-After. При ошибке store запрос пропускается.
+// Регистрируется после AuthMiddleware; пути publicPaths не ограничиваются.
+func RateLimitMiddleware(cfg config.RateLimitConfig, store ratelimit.Store) func(http.Handler) http.Handler {
+	clientLimit := ratelimit.Limit{Rate: cfg.ClientRate, Burst: cfg.ClientBurst}
+	walletLimit := ratelimit.Limit{Rate: cfg.WalletRate, Burst: cfg.WalletBurst}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			errorMiddleware(func(w http.ResponseWriter, r *http.Request) error {
+				var checks []rateLimitCheck
+				if clientLimit.Rate > 0 {
+					checks = append(checks, rateLimitCheck{"client", "client:" + rateLimitClient(r), clientLimit})
+				}
+				if walletLimit.Rate > 0 && r.Method == http.MethodPost && r.URL.Path == "/api/send" {
+					body, err := readBody(w, r)
+					if err != nil {
+						return err
+					}
+					// Некорректное тело отклонит обработчик, здесь достаточно поля from.
+					// Корзина кошелька расходуется, только если вызывающему доступен этот кошелёк:
+					// иначе чужой клиент мог бы исчерпать лимит переводов с него запросами, которые получат 403.
+					var req struct {
+						From uuid.UUID `json:"from"`
+					}
+					if json.Unmarshal(body, &req) == nil && auth.FromContext(r.Context()).CanAccessWallet(req.From) {
+						checks = append(checks, rateLimitCheck{"wallet", "wallet:" + req.From.String(), walletLimit})
+					}
+				}
+
+				var tightest *ratelimit.Result
+				for _, c := range checks {
+					res, err := store.Take(r.Context(), c.key, c.limit)
+					if err != nil {
+						slog.WarnContext(r.Context(), "rate limiter store failed", slog.String("error", err.Error()))
+						continue
+					}
+					if !res.Allowed {
+						setRateLimitHeaders(w, res)
+						rateLimitedTotal.Inc(c.name)
+						logging.AddAttrs(r.Context(), slog.String("rate_limit", c.name))
+						return &HTTPError{Status: http.StatusTooManyRequests, Code: "rate_limited", Message: "rate limit exceeded for " + c.name}
+					}
+					if tightest == nil || res.Remaining < tightest.Remaining {
+						tightest = &res
+					}
+				}
+				if tightest != nil {
+					setRateLimitHeaders(w, *tightest)
+				}
+
+				next.ServeHTTP(w, r)
+				return nil
+			})(w, r)
+		})
+	}
+}
+
+// rateLimitCheck — корзина, из которой запрос должен взять токен.
+type rateLimitCheck struct {
+	name  string // Метка метрики и текст ошибки: client или wallet
+	key   string
+	limit ratelimit.Limit
+}
+
+// rateLimitClient возвращает ключ клиента: субъект запроса или адрес, если запрос не аутентифицирован.
+func rateLimitClient(r *http.Request) string {
+	if subject := auth.Subject(r.Context()); subject != "" {
+		return subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// setRateLimitHeaders записывает заголовки RateLimit-* и, при отказе, Retry-After в секундах с округлением вверх.
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	}
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
 // HandlerFunc — пользовательский тип обработчика, возвращающий ошибку.
 type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
 
-// errorMiddleware оборачивает HandlerFunc, логирует ошибки и возвращает корректный JSON.
+// errorMiddleware оборачивает HandlerFunc, добавляет ошибку к записи лога запроса
+// и возвращает корректный JSON.
 func errorMiddleware(h HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := h(w, r); err != nil {
-			log.Printf("[ERROR] %v", err)
-
-			var httpErr *HTTPError
-			if errors.As(err, &httpErr) {
-				writeJSON(w, httpErr.Status, httpErr)
-				return
+			httpErr := toHTTPError(err)
+			logging.AddAttrs(r.Context(), slog.String("error", err.Error()))
+			if httpErr.Code != "" {
+				logging.AddAttrs(r.Context(), slog.String("error_code", httpErr.Code))
 			}
 
-			// Неизвестная ошибка
-			writeJSON(w, http.StatusInternalServerError, HTTPError{Message: "internal server error"})
+			writeJSON(w, httpErr.Status, httpErr)
 		}
 	}
 }
@@ -74,6 +564,6 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		log.Printf("[WARN] Failed to encode JSON: %v", err)
+		slog.Warn("failed to encode JSON response", slog.String("error", err.Error()))
 	}
 }
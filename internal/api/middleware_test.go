@@ -0,0 +1,215 @@
+package api
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/config"
+	"golang-server/internal/model"
+	"golang-server/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withPrincipal оборачивает h так, что запросы выполняются от имени p.
+func withPrincipal(p *auth.Principal, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+func TestRateLimitWalletBucketRequiresAccess(t *testing.T) {
+	cfg := config.RateLimitConfig{WalletRate: 0.001, WalletBurst: 1}
+	limited := RateLimitMiddleware(cfg, ratelimit.NewMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	wallet := uuid.New()
+	owner := &auth.Principal{Subject: "owner", Scopes: []auth.Scope{auth.ScopeTransferWrite}, Wallets: []uuid.UUID{wallet}}
+	stranger := &auth.Principal{Subject: "stranger", Scopes: []auth.Scope{auth.ScopeTransferWrite}, Wallets: []uuid.UUID{uuid.New()}}
+	send := func(p *auth.Principal) int {
+		body := fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, wallet, uuid.New())
+		rec := httptest.NewRecorder()
+		withPrincipal(p, limited).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/send", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	// Переводы с чужого кошелька отклонит authorizeWallet, корзину кошелька они не расходуют
+	for i := range 3 {
+		if code := send(stranger); code != http.StatusOK {
+			t.Fatalf("stranger request #%d: status %d, want it passed to the handler", i, code)
+		}
+	}
+	if code := send(owner); code != http.StatusOK {
+		t.Fatalf("owner request: status %d, want %d", code, http.StatusOK)
+	}
+	if code := send(owner); code != http.StatusTooManyRequests {
+		t.Errorf("second owner request: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestAuthMiddlewareAPIKey(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	wallet := s.newWallet(t, "10")
+	admin := s.issueKey(t, model.APIKeyAdmin)
+
+	rec := s.do(t, http.MethodGet, "/api/wallet/"+wallet.String(), "", "")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != "Bearer" || errorCode(t, rec) != "unauthorized" {
+		t.Errorf("request without credentials: status %d, WWW-Authenticate %q, body %s", rec.Code, rec.Header().Get("WWW-Authenticate"), rec.Body)
+	}
+	rec = s.do(t, http.MethodGet, "/api/wallet/"+wallet.String(), "unknown-key", "")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+		t.Errorf("unknown key: status %d, WWW-Authenticate %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+
+	// Ключ принимается и из Authorization, и из X-API-Key
+	rec = s.do(t, http.MethodPost, "/api/admin/keys", admin, `{"name": "shop", "role": "client", "wallets": ["`+wallet.String()+`"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("issue key: status %d, body %s", rec.Code, rec.Body)
+	}
+	var issued model.IssuedAPIKeyResponse
+	decode(t, rec, &issued)
+	if rec := s.do(t, http.MethodGet, "/api/wallet/"+wallet.String(), "", "", APIKeyHeader, issued.Key); rec.Code != http.StatusOK {
+		t.Errorf("X-API-Key: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	if rec := s.do(t, http.MethodDelete, "/api/admin/keys/"+issued.Id.String(), admin, ""); rec.Code != http.StatusOK {
+		t.Fatalf("revoke key: status %d, body %s", rec.Code, rec.Body)
+	}
+	rec = s.do(t, http.MethodGet, "/api/wallet/"+wallet.String(), issued.Key, "")
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+		t.Errorf("revoked key: status %d, WWW-Authenticate %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestSignatureMiddleware(t *testing.T) {
+	cfg := &config.Config{Signing: config.SigningConfig{Clients: map[string]string{"gateway": "secret"}, Paths: []string{"/api/send"}}}
+	s := newTestServer(t, cfg)
+	from, to := s.newWallet(t, "100"), s.newWallet(t, "0")
+	key := s.issueKey(t, model.APIKeyClient, from)
+
+	body := fmt.Sprintf(`{"from": %q, "to": %q, "amount": "10"}`, from, to)
+	sign := func(nonce, body string) []string {
+		req := auth.SignedRequest{
+			Method:    http.MethodPost,
+			Path:      "/api/send",
+			Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+			Nonce:     nonce,
+			Body:      []byte(body),
+		}
+		return []string{
+			auth.SignatureClientHeader, "gateway",
+			auth.SignatureTimestampHeader, req.Timestamp,
+			auth.SignatureNonceHeader, nonce,
+			auth.SignatureHeader, auth.Sign([]byte("secret"), req),
+		}
+	}
+
+	// Обработчик читает тело, уже прочитанное middleware для проверки подписи
+	headers := sign("n1", body)
+	if rec := s.do(t, http.MethodPost, "/api/send", key, body, headers...); rec.Code != http.StatusOK {
+		t.Fatalf("signed transfer: status %d, body %s", rec.Code, rec.Body)
+	}
+	if balance, _ := s.store.GetBalance(t.Context(), to); balance.Cmp(model.MustParseMoney("10")) != 0 {
+		t.Errorf("recipient balance = %s, want 10", balance)
+	}
+
+	tampered := strings.Replace(body, `"10"`, `"90"`, 1)
+	failures := []struct {
+		name    string
+		body    string
+		headers []string
+		want    string
+	}{
+		{"replay", body, headers, "signature_replayed"},
+		{"unsigned", body, nil, "signature_missing"},
+		{"tampered body", tampered, sign("n2", body), "signature_invalid"},
+	}
+	for _, tt := range failures {
+		rec := s.do(t, http.MethodPost, "/api/send", key, tt.body, tt.headers...)
+		if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != tt.want {
+			t.Errorf("%s: status %d, body %s; want 401 %s", tt.name, rec.Code, rec.Body, tt.want)
+		}
+	}
+
+	// Пути вне signing_config.paths подписи не требуют
+	if rec := s.do(t, http.MethodGet, "/api/wallet/"+from.String(), key, ""); rec.Code != http.StatusOK {
+		t.Errorf("unsigned request to an unprotected path: status %d, body %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAuthMiddlewareJWT(t *testing.T) {
+	s := newTestServer(t, &config.Config{Auth: config.AuthConfig{JWT: jwtConfig(t)}})
+	wallet := s.newWallet(t, "10")
+	key := s.issueKey(t, model.APIKeyClient, wallet)
+	path := "/api/wallet/" + wallet.String()
+
+	token := signJWT(t, map[string]any{"sub": "alice", "scope": "wallet:read", "wallets": []string{wallet.String()}})
+	if rec := s.do(t, http.MethodGet, path, token, ""); rec.Code != http.StatusOK {
+		t.Errorf("valid token: status %d, body %s", rec.Code, rec.Body)
+	}
+	// Bearer не в формате JWT проверяется как ключ API
+	if rec := s.do(t, http.MethodGet, path, key, ""); rec.Code != http.StatusOK {
+		t.Errorf("API key with JWT enabled: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	expired := signJWT(t, map[string]any{"sub": "alice", "scope": "wallet:read", "exp": time.Now().Add(-time.Hour).Unix()})
+	tampered := token[:len(token)-2] + "AA"
+	for name, bearer := range map[string]string{"expired": expired, "tampered": tampered} {
+		rec := s.do(t, http.MethodGet, path, bearer, "")
+		if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+			t.Errorf("%s token: status %d, WWW-Authenticate %q", name, rec.Code, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+	// В X-API-Key принимаются только ключи API
+	if rec := s.do(t, http.MethodGet, path, "", "", APIKeyHeader, token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("token in X-API-Key: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// Без JWKS токены проверяются как ключи API и не проходят
+	withoutJWT := newTestServer(t, &config.Config{})
+	withoutJWT.newWallet(t, "0")
+	if rec := withoutJWT.do(t, http.MethodGet, path, token, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("token without JWKS: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ServerConfig.RateLimit = config.RateLimitConfig{ClientRate: 1, ClientBurst: 3, WalletRate: 0.001, WalletBurst: 1}
+	s := newTestServer(t, cfg)
+	from, to := s.newWallet(t, "100"), s.newWallet(t, "0")
+	client, other := s.issueKey(t, model.APIKeyClient, from), s.issueKey(t, model.APIKeyClient, to)
+	path := "/api/wallet/" + from.String()
+
+	for i := range 3 {
+		rec := s.do(t, http.MethodGet, path, client, "")
+		h := rec.Header()
+		if rec.Code != http.StatusOK || h.Get("RateLimit-Limit") != "3" || h.Get("RateLimit-Remaining") != strconv.Itoa(2-i) || h.Get("RateLimit-Reset") == "" {
+			t.Fatalf("request #%d: status %d, headers %v", i, rec.Code, h)
+		}
+	}
+	rec := s.do(t, http.MethodGet, path, client, "")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" || errorCode(t, rec) != "rate_limited" {
+		t.Errorf("request over the client limit: status %d, Retry-After %q, body %s", rec.Code, rec.Header().Get("Retry-After"), rec.Body)
+	}
+	if rec := s.do(t, http.MethodGet, "/api/wallet/"+to.String(), other, ""); rec.Code != http.StatusOK {
+		t.Errorf("another client: status %d, want its own bucket", rec.Code)
+	}
+
+	// Перевод расходует и корзину кошелька; в заголовках — самая исчерпанная корзина
+	body := fmt.Sprintf(`{"from": %q, "to": %q, "amount": "1"}`, from, to)
+	admin := s.issueKey(t, model.APIKeyAdmin)
+	rec = s.do(t, http.MethodPost, "/api/send", admin, body)
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "1" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("transfer: status %d, headers %v", rec.Code, rec.Header())
+	}
+	rec = s.do(t, http.MethodPost, "/api/send", admin, body)
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "rate limit exceeded for wallet") {
+		t.Errorf("transfer over the wallet limit: status %d, body %s", rec.Code, rec.Body)
+	}
+}
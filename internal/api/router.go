@@ -34,10 +34,12 @@ func (r *Router) Use(mw func(http.Handler) http.Handler) {
 
 // Handler возвращает http.Handler с применёнными middleware.
 // Middleware оборачиваются в обратном порядке (последний добавленный — первый вызываемый).
+// Снаружи всех middleware запрос оборачивается в span трассировки, чтобы его длительность
+// включала работу middleware, а записи лога получали идентификатор трассы.
 func (r *Router) Handler() http.Handler {
 	var handler http.Handler = r.mux
 	for i := len(r.middleware) - 1; i >= 0; i-- {
 		handler = r.middleware[i](handler)
 	}
-	return handler
+	return tracingMiddleware(handler)
 }
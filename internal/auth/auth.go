@@ -0,0 +1,96 @@
+// Package auth описывает вызывающего (Principal) и ключи API, которыми он аутентифицируется.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"slices"
+)
+
+// KeyPrefix — префикс ключей API. По нему ключ легко найти в логах и сканерах секретов.
+const KeyPrefix = "wk_"
+
+// keyBytes — длина случайной части ключа в байтах.
+const keyBytes = 32
+
+// Scope — право на группу операций.
+type Scope string
+
+const (
+	ScopeWalletRead    Scope = "wallet:read"    // Чтение баланса, информации и истории кошелька
+	ScopeWalletWrite   Scope = "wallet:write"   // Заморозка и закрытие кошелька владельцем
+	ScopeTransferWrite Scope = "transfer:write" // Переводы с кошелька
+	ScopeAdmin         Scope = "admin"          // Все кошельки и административные операции
+)
+
+// ClientScopes — права ключа API с ролью client.
+var ClientScopes = []Scope{ScopeWalletRead, ScopeWalletWrite, ScopeTransferWrite}
+
+// AdminScopes — права ключа API с ролью admin.
+var AdminScopes = []Scope{ScopeWalletRead, ScopeWalletWrite, ScopeTransferWrite, ScopeAdmin}
+
+// Principal — аутентифицированный вызывающий.
+type Principal struct {
+	Subject string      // Кто выполняет запрос: sub токена или api_key:<ID ключа>; попадает в журнал аудита
+	KeyId   uuid.UUID   // ID ключа API; uuid.Nil для токенов и при отключённой аутентификации
+	Name    string      // Имя ключа для журнала
+	Scopes  []Scope     // Разрешённые группы операций
+	Wallets []uuid.UUID // Кошельки, доступные вызывающему без права admin
+}
+
+// HasScope сообщает, есть ли у вызывающего право scope.
+func (p *Principal) HasScope(scope Scope) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+// CanAccessWallet сообщает, относится ли кошелёк к вызывающему.
+// Какие операции с кошельком разрешены, определяют права Scopes.
+func (p *Principal) CanAccessWallet(walletId uuid.UUID) bool {
+	if p == nil {
+		return false
+	}
+	return p.HasScope(ScopeAdmin) || slices.Contains(p.Wallets, walletId)
+}
+
+// principalKey — ключ контекста для Principal.
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с аутентифицированным вызывающим.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает вызывающего из контекста или nil, если запрос не аутентифицирован.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Subject возвращает субъект аутентифицированного запроса или пустую строку.
+func Subject(ctx context.Context) string {
+	if p := FromContext(ctx); p != nil {
+		return p.Subject
+	}
+	return ""
+}
+
+// GenerateKey создаёт новый ключ API и возвращает его вместе с хешем для хранения.
+func GenerateKey() (key, hash string, err error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = KeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, HashKey(key), nil
+}
+
+// HashKey возвращает SHA-256 хеш ключа в шестнадцатеричном виде.
+// Ключ содержит 256 случайных бит, поэтому медленная хеш-функция для паролей не нужна.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, hash, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if !strings.HasPrefix(key, KeyPrefix) || len(hash) != 64 || HashKey(key) != hash {
+		t.Fatalf("GenerateKey = %q, %q", key, hash)
+	}
+
+	other, _, err := GenerateKey()
+	if err != nil || other == key {
+		t.Errorf("second GenerateKey = %q, %v, want a different key", other, err)
+	}
+}
+
+func TestPrincipalCanAccessWallet(t *testing.T) {
+	own, foreign := uuid.New(), uuid.New()
+
+	client := &Principal{Subject: "shop", Scopes: ClientScopes, Wallets: []uuid.UUID{own}}
+	if !client.CanAccessWallet(own) || client.CanAccessWallet(foreign) || client.HasScope(ScopeAdmin) {
+		t.Error("client must access only its own wallets")
+	}
+	if admin := (&Principal{Scopes: AdminScopes}); !admin.CanAccessWallet(foreign) {
+		t.Error("admin must access any wallet")
+	}
+
+	ctx := WithPrincipal(t.Context(), client)
+	if FromContext(ctx) != client || Subject(ctx) != "shop" {
+		t.Error("FromContext did not return the stored principal")
+	}
+	if p := FromContext(t.Context()); p != nil || p.CanAccessWallet(own) {
+		t.Error("unauthenticated request must not access wallets")
+	}
+}
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые алгоритмы подписи токенов.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+var (
+	// ErrTokenInvalid возвращается, если токен повреждён, подписан неизвестным ключом или не прошёл проверку подписи.
+	ErrTokenInvalid = errors.New("invalid token")
+	// ErrTokenExpired возвращается, если срок действия токена истёк или ещё не наступил.
+	ErrTokenExpired = errors.New("token is expired or not yet valid")
+)
+
+// jwk — ключ из JWKS (RFC 7517). Поддерживаются симметричные ключи (kty oct) для HS256
+// и открытые ключи RSA для RS256.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"` // Симметричный ключ, base64url
+	N   string `json:"n"` // Модуль RSA, base64url
+	E   string `json:"e"` // Экспонента RSA, base64url
+}
+
+// verificationKey — ключ проверки подписи с известным алгоритмом.
+type verificationKey struct {
+	kid    string
+	alg    string
+	secret []byte
+	rsa    *rsa.PublicKey
+}
+
+// loadJWKS читает ключи проверки подписи из файла JWKS: {"keys": [...]}.
+// Алгоритм ключа определяется его типом; поле alg, если задано, должно ему соответствовать.
+// Ключи с use, отличным от sig, пропускаются.
+func loadJWKS(path string) ([]verificationKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS %s: %w", path, err)
+	}
+
+	keys := make([]verificationKey, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key #%d (kid %q): %w", i, k.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS %s contains no signing keys", path)
+	}
+	return keys, nil
+}
+
+// verificationKey разбирает ключ JWKS.
+func (k jwk) verificationKey() (verificationKey, error) {
+	key := verificationKey{kid: k.Kid}
+	switch k.Kty {
+	case "oct":
+		key.alg = AlgHS256
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return key, errors.New("invalid symmetric key")
+		}
+		key.secret = secret
+	case "RSA":
+		key.alg = AlgRS256
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return key, errors.New("invalid RSA public key")
+		}
+		key.rsa = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.rsa.N.BitLen() < 2048 {
+			return key, errors.New("RSA key must be at least 2048 bits")
+		}
+	default:
+		return key, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	if k.Alg != "" && k.Alg != key.alg {
+		return key, fmt.Errorf("algorithm %s does not match key type %s", k.Alg, k.Kty)
+	}
+	return key, nil
+}
+
+// JWTVerifier проверяет токены доступа поставщика удостоверений.
+type JWTVerifier struct {
+	keys     []verificationKey
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWTVerifier загружает ключи из cfg.JWKSFile и создаёт JWTVerifier.
+func NewJWTVerifier(cfg config.JWTConfig) (*JWTVerifier, error) {
+	keys, err := loadJWKS(cfg.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTVerifier{
+		keys:     keys,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   time.Duration(cfg.Leeway),
+		now:      time.Now,
+	}, nil
+}
+
+// IsJWT сообщает, похож ли bearer-токен на JWT (три части через точку), а не на ключ API.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// jwtHeader — заголовок JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims — проверяемые утверждения токена. Права передаются строкой scope через пробел
+// (RFC 8693) или массивом scp; доступные кошельки — массивом wallets.
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       []string        `json:"scp"`
+	Wallets   []uuid.UUID     `json:"wallets"`
+}
+
+// Verify проверяет подпись, срок действия, издателя и аудиторию токена
+// и возвращает вызывающего. Утверждения sub и exp обязательны.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenInvalid
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrTokenInvalid)
+	}
+	key, err := v.key(header)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrTokenInvalid)
+	}
+	if !key.verify(parts[0]+"."+parts[1], signature) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrTokenInvalid)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrTokenInvalid)
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+
+	scopes := make([]Scope, 0)
+	for _, s := range append(strings.Fields(claims.Scope), claims.Scp...) {
+		if !slices.Contains(scopes, Scope(s)) {
+			scopes = append(scopes, Scope(s))
+		}
+	}
+	wallets := claims.Wallets
+	if wallets == nil {
+		wallets = []uuid.UUID{}
+	}
+	return &Principal{Subject: claims.Subject, Scopes: scopes, Wallets: wallets}, nil
+}
+
+// key выбирает ключ проверки по kid и алгоритму заголовка. Алгоритм обязан совпадать
+// с алгоритмом ключа, поэтому открытый ключ RSA нельзя использовать как секрет HS256.
+// Токен без kid допускается, только если подходящий ключ единственный.
+func (v *JWTVerifier) key(header jwtHeader) (verificationKey, error) {
+	if header.Alg != AlgHS256 && header.Alg != AlgRS256 {
+		return verificationKey{}, fmt.Errorf("%w: unsupported algorithm %q", ErrTokenInvalid, header.Alg)
+	}
+
+	var found []verificationKey
+	for _, k := range v.keys {
+		if k.alg == header.Alg && (header.Kid == "" || k.kid == header.Kid) {
+			found = append(found, k)
+		}
+	}
+	if len(found) != 1 {
+		return verificationKey{}, fmt.Errorf("%w: unknown signing key %q", ErrTokenInvalid, header.Kid)
+	}
+	return found[0], nil
+}
+
+// verify проверяет подпись signingInput.
+func (k verificationKey) verify(signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	if k.rsa != nil {
+		return rsa.VerifyPKCS1v15(k.rsa, crypto.SHA256, digest[:], signature) == nil
+	}
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write([]byte(signingInput))
+	return hmac.Equal(mac.Sum(nil), signature)
+}
+
+// validate проверяет утверждения токена с допуском leeway на расхождение часов.
+func (v *JWTVerifier) validate(c jwtClaims) error {
+	if c.Subject == "" {
+		return fmt.Errorf("%w: sub claim is required", ErrTokenInvalid)
+	}
+	if c.ExpiresAt == nil {
+		return fmt.Errorf("%w: exp claim is required", ErrTokenInvalid)
+	}
+	now := v.now()
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(v.leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != nil && now.Add(v.leeway).Before(time.Unix(*c.NotBefore, 0)) {
+		return ErrTokenExpired
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrTokenInvalid)
+	}
+	if v.audience != "" && !audienceContains(c.Audience, v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrTokenInvalid)
+	}
+	return nil
+}
+
+// audienceContains проверяет утверждение aud, которое может быть строкой или массивом строк.
+func audienceContains(raw json.RawMessage, audience string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == audience
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return slices.Contains(list, audience)
+	}
+	return false
+}
+
+// decodeSegment декодирует часть токена в формате base64url без выравнивания.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"golang-server/internal/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hsSecret — симметричный ключ тестового JWKS.
+var hsSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// testJWTVerifier записывает JWKS с ключом HS256 и открытым ключом rsaKey и создаёт JWTVerifier.
+func testJWTVerifier(t *testing.T, rsaKey *rsa.PrivateKey) *JWTVerifier {
+	t.Helper()
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "oct", "kid": "hs", "k": b64(hsSecret)},
+		{"kty": "RSA", "kid": "rs", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewJWTVerifier(config.JWTConfig{JWKSFile: path, Issuer: "idp", Audience: "wallet"})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	return v
+}
+
+// signToken формирует JWT с заголовком header и утверждениями claims.
+func signToken(t *testing.T, header, claims map[string]any, sign func(input []byte) []byte) string {
+	t.Helper()
+
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(input)))
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := testJWTVerifier(t, rsaKey)
+
+	hs := func(input []byte) []byte { return hmacSHA256(hsSecret, input) }
+	rs := func(input []byte) []byte {
+		digest := sha256.Sum256(input)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	claims := func(mutate func(map[string]any)) map[string]any {
+		c := map[string]any{
+			"sub":     "user-1",
+			"iss":     "idp",
+			"aud":     []string{"other", "wallet"},
+			"exp":     time.Now().Add(time.Minute).Unix(),
+			"scope":   "wallet:read transfer:write",
+			"wallets": []string{"8327bba3-d2f8-4445-b04c-00d7c0dbe80a"},
+		}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+
+	p, err := v.Verify(signToken(t, map[string]any{"alg": "HS256", "kid": "hs"}, claims(nil), hs))
+	if err != nil {
+		t.Fatalf("Verify HS256: %v", err)
+	}
+	if p.Subject != "user-1" || !p.HasScope(ScopeTransferWrite) || p.HasScope(ScopeAdmin) || len(p.Wallets) != 1 {
+		t.Errorf("principal = %+v", p)
+	}
+
+	if _, err := v.Verify(signToken(t, map[string]any{"alg": "RS256"}, claims(nil), rs)); err != nil {
+		t.Errorf("Verify RS256 without kid: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", signToken(t, map[string]any{"alg": "HS256", "kid": "hs"}, claims(func(c map[string]any) {
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+		}), hs), ErrTokenExpired},
+		{"wrong audience", signToken(t, map[string]any{"alg": "HS256", "kid": "hs"}, claims(func(c map[string]any) {
+			c["aud"] = "other"
+		}), hs), ErrTokenInvalid},
+		{"no sub", signToken(t, map[string]any{"alg": "HS256", "kid": "hs"}, claims(func(c map[string]any) {
+			delete(c, "sub")
+		}), hs), ErrTokenInvalid},
+		{"alg none", signToken(t, map[string]any{"alg": "none"}, claims(nil), func([]byte) []byte { return nil }), ErrTokenInvalid},
+		{"RSA key as HMAC secret", signToken(t, map[string]any{"alg": "HS256", "kid": "rs"}, claims(nil), func(input []byte) []byte {
+			return hmacSHA256(rsaKey.N.Bytes(), input)
+		}), ErrTokenInvalid},
+		{"tampered claims", tamper(
+			signToken(t, map[string]any{"alg": "RS256", "kid": "rs"}, claims(nil), rs),
+			signToken(t, map[string]any{"alg": "RS256", "kid": "rs"}, claims(func(c map[string]any) { c["scope"] = "admin" }), hs),
+		), ErrTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(tt.token); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// tamper возвращает token с утверждениями из other и исходной подписью.
+func tamper(token, other string) string {
+	parts, otherParts := strings.Split(token, "."), strings.Split(other, ".")
+	return parts[0] + "." + otherParts[1] + "." + parts[2]
+}
+
+// hmacSHA256 подписывает input ключом secret.
+func hmacSHA256(secret, input []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
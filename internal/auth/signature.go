@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Заголовки подписи запроса.
+const (
+	SignatureClientHeader    = "X-Signature-Client"    // ID клиента, по которому выбирается общий секрет
+	SignatureTimestampHeader = "X-Signature-Timestamp" // Время подписи, секунды Unix
+	SignatureNonceHeader     = "X-Signature-Nonce"     // Уникальная для клиента строка, защищает от повтора
+	SignatureHeader          = "X-Signature"           // HMAC-SHA256 канонической строки в шестнадцатеричном виде
+)
+
+// maxNonceLength — максимальная длина nonce.
+const maxNonceLength = 128
+
+var (
+	// ErrSignatureMissing возвращается, если запрос не содержит заголовков подписи.
+	ErrSignatureMissing = errors.New("request signature is required")
+	// ErrSignatureInvalid возвращается при неизвестном клиенте, некорректных заголовках или неверной подписи.
+	ErrSignatureInvalid = errors.New("request signature is invalid")
+	// ErrSignatureExpired возвращается, если время подписи вне допустимого окна.
+	ErrSignatureExpired = errors.New("request timestamp is outside the allowed window")
+	// ErrSignatureReplayed возвращается, если nonce клиента уже использован.
+	ErrSignatureReplayed = errors.New("request nonce was already used")
+)
+
+// SignedRequest — подписываемые части запроса.
+type SignedRequest struct {
+	Method    string
+	Path      string // Путь вместе со строкой запроса, как в URL.RequestURI
+	Timestamp string
+	Nonce     string
+	Body      []byte
+}
+
+// canonical возвращает подписываемую строку: метод, путь, время, nonce
+// и SHA-256 тела, разделённые переводом строки.
+func (r SignedRequest) canonical() string {
+	bodyHash := sha256.Sum256(r.Body)
+	return r.Method + "\n" + r.Path + "\n" + r.Timestamp + "\n" + r.Nonce + "\n" + hex.EncodeToString(bodyHash[:])
+}
+
+// Sign вычисляет подпись запроса общим секретом клиента.
+func Sign(secret []byte, req SignedRequest) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(req.canonical()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier проверяет подписи запросов клиентов с общими секретами.
+type Verifier struct {
+	secrets map[string][]byte
+	skew    time.Duration
+	nonces  *nonceCache
+	now     func() time.Time
+}
+
+// NewVerifier создаёт Verifier. clients сопоставляет ID клиента с общим секретом,
+// skew — допустимое расхождение часов клиента и сервера в обе стороны.
+func NewVerifier(clients map[string]string, skew time.Duration) (*Verifier, error) {
+	secrets := make(map[string][]byte, len(clients))
+	for id, secret := range clients {
+		if id == "" || secret == "" {
+			return nil, fmt.Errorf("client %q: client ID and secret must not be empty", id)
+		}
+		secrets[id] = []byte(secret)
+	}
+	return &Verifier{secrets: secrets, skew: skew, nonces: newNonceCache(), now: time.Now}, nil
+}
+
+// Verify проверяет подпись запроса клиента clientID. Nonce запоминается только после
+// успешной проверки подписи, поэтому заполнить кэш без секрета нельзя.
+// Возвращает ErrSignatureMissing, ErrSignatureInvalid, ErrSignatureExpired или ErrSignatureReplayed.
+func (v *Verifier) Verify(clientID, signature string, req SignedRequest) error {
+	if clientID == "" && signature == "" && req.Timestamp == "" && req.Nonce == "" {
+		return ErrSignatureMissing
+	}
+	secret, ok := v.secrets[clientID]
+	if !ok || req.Nonce == "" || len(req.Nonce) > maxNonceLength {
+		return ErrSignatureInvalid
+	}
+
+	ts, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	now := v.now()
+	if d := now.Sub(time.Unix(ts, 0)); d > v.skew || d < -v.skew {
+		return ErrSignatureExpired
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	want, _ := hex.DecodeString(Sign(secret, req))
+	if !hmac.Equal(got, want) {
+		return ErrSignatureInvalid
+	}
+
+	// Подпись с другим nonce истечёт раньше, чем nonce будет забыт
+	if !v.nonces.add(clientID+"\n"+req.Nonce, now.Add(2*v.skew), now) {
+		return ErrSignatureReplayed
+	}
+	return nil
+}
+
+// minNonceSweep — размер кэша nonce, при котором начинается удаление истёкших записей.
+const minNonceSweep = 1024
+
+// nonceCache запоминает использованные nonce до истечения окна, в котором подпись действительна.
+// Кэш локален для процесса: за балансировщиком с несколькими репликами повтор на другую реплику не обнаруживается.
+type nonceCache struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	nextSweep int
+}
+
+func newNonceCache() *nonceCache {
+	return &nonceCache{expires: make(map[string]time.Time), nextSweep: minNonceSweep}
+}
+
+// add запоминает nonce до expiresAt и возвращает false, если он уже использован.
+// Истёкшие записи удаляются, когда кэш вырастает вдвое с прошлой очистки.
+func (c *nonceCache) add(nonce string, expiresAt, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if exp, ok := c.expires[nonce]; ok && exp.After(now) {
+		return false
+	}
+	c.expires[nonce] = expiresAt
+
+	if len(c.expires) >= c.nextSweep {
+		for k, exp := range c.expires {
+			if !exp.After(now) {
+				delete(c.expires, k)
+			}
+		}
+		c.nextSweep = max(minNonceSweep, 2*len(c.expires))
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	v, err := NewVerifier(map[string]string{"gateway": "secret"}, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	v.now = func() time.Time { return now }
+
+	signed := func(nonce string, ts time.Time) SignedRequest {
+		return SignedRequest{
+			Method:    "POST",
+			Path:      "/api/send",
+			Timestamp: strconv.FormatInt(ts.Unix(), 10),
+			Nonce:     nonce,
+			Body:      []byte(`{"amount":"1"}`),
+		}
+	}
+
+	req := signed("n1", now)
+	sig := Sign([]byte("secret"), req)
+	if err := v.Verify("gateway", sig, req); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := v.Verify("gateway", sig, req); !errors.Is(err, ErrSignatureReplayed) {
+		t.Errorf("replayed request: %v, want ErrSignatureReplayed", err)
+	}
+
+	tampered := signed("n2", now)
+	sig = Sign([]byte("secret"), tampered)
+	tampered.Body = []byte(`{"amount":"1000"}`)
+	if err := v.Verify("gateway", sig, tampered); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("tampered body: %v, want ErrSignatureInvalid", err)
+	}
+	// Неудачная проверка не расходует nonce
+	tampered.Body = []byte(`{"amount":"1"}`)
+	if err := v.Verify("gateway", sig, tampered); err != nil {
+		t.Errorf("nonce of a rejected request was consumed: %v", err)
+	}
+
+	old := signed("n3", now.Add(-6*time.Minute))
+	if err := v.Verify("gateway", Sign([]byte("secret"), old), old); !errors.Is(err, ErrSignatureExpired) {
+		t.Errorf("stale timestamp: %v, want ErrSignatureExpired", err)
+	}
+
+	other := signed("n4", now)
+	if err := v.Verify("unknown", Sign([]byte("secret"), other), other); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("unknown client: %v, want ErrSignatureInvalid", err)
+	}
+	if err := v.Verify("", "", SignedRequest{Method: "POST", Path: "/api/send"}); !errors.Is(err, ErrSignatureMissing) {
+		t.Errorf("unsigned request: %v, want ErrSignatureMissing", err)
+	}
+}
+
+func TestNonceCacheSweep(t *testing.T) {
+	c := newNonceCache()
+	now := time.Unix(0, 0)
+	for i := range minNonceSweep {
+		c.add(strconv.Itoa(i), now.Add(time.Second), now)
+	}
+	later := now.Add(time.Minute)
+	if !c.add("0", later.Add(time.Second), later) {
+		t.Error("expired nonce was rejected")
+	}
+	// Кэш вырос вдвое с прошлой очистки: истёкшие записи удаляются
+	for i := range minNonceSweep {
+		c.add("fresh"+strconv.Itoa(i), later.Add(time.Second), later)
+	}
+	if len(c.expires) != minNonceSweep+1 {
+		t.Errorf("cache holds %d entries after sweep, want %d", len(c.expires), minNonceSweep+1)
+	}
+}
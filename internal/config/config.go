@@ -13,8 +13,15 @@ type duration time.Duration
 
 // Config хранит конфигурацию приложения, включая базу данных и сервер.
 type Config struct {
-	DbConfig     DbConfig     `json:"db_config"`
-	ServerConfig ServerConfig `json:"server_config"`
+	DbConfig     DbConfig      `json:"db_config"`
+	ServerConfig ServerConfig  `json:"server_config"`
+	Seed         SeedConfig    `json:"seed"`
+	LogConfig    LogConfig     `json:"log_config"`
+	Tracing      TracingConfig `json:"tracing_config"`
+	Auth         AuthConfig    `json:"auth_config"`
+	Signing      SigningConfig `json:"signing_config"`
+	Limits       LimitsConfig  `json:"limits_config"`
+	Fees         FeesConfig    `json:"fees_config"`
 }
 
 // ServerConfig хранит настройки сервера.
@@ -23,17 +30,128 @@ type ServerConfig struct {
 	Port        string   `json:"port"`         // Порт сервера, например "8080"
 	Timeout     duration `json:"timeout"`      // Время ожидания запроса
 	IdleTimeout duration `json:"idle_timeout"` // Время простоя соединения
+
+	ShutdownDelay   duration `json:"shutdown_delay"`   // Пауза между отказом /readyz и закрытием сокета при остановке
+	ShutdownTimeout duration `json:"shutdown_timeout"` // Время на завершение обрабатываемых запросов при остановке
+
+	RateLimit RateLimitConfig `json:"rate_limit"` // Ограничение частоты запросов
+}
+
+// RateLimitConfig хранит лимиты частоты запросов (token bucket). Нулевая скорость отключает лимит.
+type RateLimitConfig struct {
+	ClientRate  float64 `json:"client_rate"`  // Запросов в секунду от одного клиента (ключа API или субъекта токена)
+	ClientBurst int     `json:"client_burst"` // Запросов подряд сверх скорости; по умолчанию — скорость, но не меньше 1
+	WalletRate  float64 `json:"wallet_rate"`  // Переводов в секунду с одного кошелька
+	WalletBurst int     `json:"wallet_burst"` // Переводов подряд с одного кошелька
 }
 
 // DbConfig хранит настройки подключения к базе данных.
 type DbConfig struct {
-	DbName     string `json:"dbname"`      // Имя базы данных
-	User       string `json:"user"`        // Пользователь базы данных
-	Password   string `json:"password"`    // Пароль пользователя
-	Host       string `json:"host"`        // Адрес базы данных
-	Port       string `json:"port"`        // Порт базы данных
-	SSLMode    string `json:"sslmode"`     // Режим SSL (disable, require и т.д.)
-	InitScript string `json:"init_script"` // Путь к скрипту инициализации
+	Driver   string `json:"driver"`   // Тип базы данных: postgres или sqlite
+	Path     string `json:"path"`     // Путь к файлу базы данных SQLite
+	DbName   string `json:"dbname"`   // Имя базы данных
+	User     string `json:"user"`     // Пользователь базы данных
+	Password string `json:"password"` // Пароль пользователя
+	Host     string `json:"host"`     // Адрес базы данных
+	Port     string `json:"port"`     // Порт базы данных
+	SSLMode  string `json:"sslmode"`  // Режим SSL (disable, require и т.д.)
+
+	MaxOpenConns int `json:"max_open_conns"` // Максимум открытых соединений пула PostgreSQL
+
+	SkipMigrations bool `json:"skip_migrations"` // Не применять миграции при запуске (только командой migrate)
+
+	ReadTimeout  duration `json:"read_timeout"`  // Таймаут одной операции чтения
+	WriteTimeout duration `json:"write_timeout"` // Таймаут одной операции записи вместе с повторами транзакции
+
+	TxMaxRetries     int      `json:"tx_max_retries"`      // Максимум повторов транзакции при конфликте сериализации
+	TxRetryBaseDelay duration `json:"tx_retry_base_delay"` // Начальная задержка перед повтором
+	TxRetryMaxDelay  duration `json:"tx_retry_max_delay"`  // Верхняя граница задержки перед повтором
+}
+
+// SeedConfig хранит настройки начального наполнения пустого хранилища кошельками.
+type SeedConfig struct {
+	Wallets int    `json:"wallets"` // Количество создаваемых кошельков; 0 отключает наполнение
+	Balance string `json:"balance"` // Начальный баланс каждого кошелька, например "100.0"
+}
+
+// LogConfig хранит настройки журнала.
+type LogConfig struct {
+	Level  string `json:"level"`  // Минимальный уровень записей: debug, info, warn или error
+	Format string `json:"format"` // Формат записей: json или text
+}
+
+// TracingConfig хранит настройки трассировки запросов.
+type TracingConfig struct {
+	Exporter    string            `json:"exporter"`     // Куда отправлять span'ы: none, stdout, file или otlp
+	File        string            `json:"file"`         // Файл для exporter = file, span'ы дописываются построчно в JSON
+	Endpoint    string            `json:"endpoint"`     // URL приёмника OTLP/HTTP, например http://collector:4318/v1/traces
+	Headers     map[string]string `json:"headers"`      // Дополнительные заголовки запросов к приёмнику OTLP
+	ServiceName string            `json:"service_name"` // Значение service.name в экспортируемых span'ах
+	SampleRatio float64           `json:"sample_ratio"` // Доля записываемых трасс, начатых этим сервисом; 0 — все
+}
+
+// AuthConfig хранит настройки аутентификации запросов.
+type AuthConfig struct {
+	Disabled bool      `json:"disabled"` // Выполнять все запросы с правами администратора без ключа API; только для разработки
+	JWT      JWTConfig `json:"jwt"`      // Токены поставщика удостоверений; проверка включается заданием jwks_file
+}
+
+// JWTConfig хранит настройки проверки токенов доступа (JWT).
+type JWTConfig struct {
+	JWKSFile string   `json:"jwks_file"` // Локальный файл JWKS с ключами HS256 (kty oct) и RS256 (kty RSA)
+	Issuer   string   `json:"issuer"`    // Ожидаемое значение iss; пусто — не проверяется
+	Audience string   `json:"audience"`  // Значение, которое должно входить в aud; пусто — не проверяется
+	Leeway   duration `json:"leeway"`    // Допуск на расхождение часов при проверке exp и nbf
+}
+
+// SigningConfig хранит настройки подписи запросов HMAC-SHA256 для межсервисных вызовов.
+// Проверка включается, если задан хотя бы один клиент.
+type SigningConfig struct {
+	Clients   map[string]string `json:"clients"`    // ID клиента → общий секрет
+	Paths     []string          `json:"paths"`      // Пути, запросы к которым обязаны быть подписаны
+	ClockSkew duration          `json:"clock_skew"` // Допустимое расхождение часов клиента и сервера
+}
+
+// LimitsConfig хранит лимиты исходящих переводов: общие для всех кошельков
+// и переопределения для отдельных кошельков.
+type LimitsConfig struct {
+	TransferLimitsConfig
+	Wallets map[string]TransferLimitsConfig `json:"wallets"` // UUID кошелька → переопределённые лимиты
+}
+
+// TransferLimitsConfig хранит лимиты исходящих переводов кошелька. В общих лимитах пустое
+// значение отключает лимит, в переопределении — оставляет общий; "unlimited" и -1 отключают лимит.
+type TransferLimitsConfig struct {
+	MaxTransfer     string `json:"max_transfer"`     // Максимальная сумма одного перевода
+	DailyOutgoing   string `json:"daily_outgoing"`   // Максимальная сумма переводов за сутки UTC
+	MonthlyOutgoing string `json:"monthly_outgoing"` // Максимальная сумма переводов за календарный месяц UTC
+	HourlyCount     int    `json:"hourly_count"`     // Максимальное число переводов за последний час
+}
+
+// FeesConfig хранит расписание комиссий за переводы: общее для всех кошельков-отправителей
+// и расписания отдельных кошельков, которые заменяют общее целиком.
+type FeesConfig struct {
+	CollectionWallet string `json:"collection_wallet"` // UUID кошелька, на который зачисляются комиссии
+	FeeScheduleConfig
+	Wallets map[string]FeeScheduleConfig `json:"wallets"` // UUID кошелька-отправителя → расписание
+}
+
+// FeeScheduleConfig — расписание комиссий: фиксированная часть и процент от суммы перевода
+// либо ступени по сумме перевода, а также ограничения комиссии снизу и сверху.
+type FeeScheduleConfig struct {
+	Flat    string          `json:"flat"`    // Фиксированная комиссия
+	Percent string          `json:"percent"` // Процент от суммы перевода, например "1.5"
+	Tiers   []FeeTierConfig `json:"tiers"`   // Ступени по возрастанию up_to; заменяют flat и percent
+	Min     string          `json:"min"`     // Минимальная комиссия
+	Max     string          `json:"max"`     // Максимальная комиссия
+}
+
+// FeeTierConfig — ступень расписания комиссий для переводов до UpTo включительно.
+// Последняя ступень задаётся без up_to и действует для больших сумм.
+type FeeTierConfig struct {
+	UpTo    string `json:"up_to"`
+	Flat    string `json:"flat"`
+	Percent string `json:"percent"`
 }
 
 var (
@@ -46,9 +164,8 @@ var (
 func LoadConfig(r io.Reader) *Config {
 	once.Do(func() {
 		if r == nil {
-			log.Panic("reader обязателен при первом вызове LoadConfig")
+			log.Panic("LoadConfig: reader is required on the first call")
 		}
-		log.Println("Запуск загрузки конфигурации")
 
 		js, err := io.ReadAll(r)
 		if err != nil {
@@ -62,7 +179,6 @@ func LoadConfig(r io.Reader) *Config {
 
 		c.SetDefaults()
 		cfg = &c
-		log.Println("Конфигурация загружена")
 	})
 	return cfg
 }
@@ -81,7 +197,7 @@ func (d *duration) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// SetDefaults устанавливает значения по умолчанию для сервера.
+// SetDefaults устанавливает значения по умолчанию для сервера и базы данных.
 func (c *Config) SetDefaults() {
 	if c.ServerConfig.Host == "" {
 		c.ServerConfig.Host = "127.0.0.1"
@@ -95,4 +211,70 @@ func (c *Config) SetDefaults() {
 	if c.ServerConfig.IdleTimeout == 0 {
 		c.ServerConfig.IdleTimeout = duration(5 * time.Minute)
 	}
+	if c.ServerConfig.ShutdownTimeout == 0 {
+		c.ServerConfig.ShutdownTimeout = duration(15 * time.Second)
+	}
+	if c.ServerConfig.RateLimit.ClientBurst <= 0 {
+		c.ServerConfig.RateLimit.ClientBurst = max(1, int(c.ServerConfig.RateLimit.ClientRate))
+	}
+	if c.ServerConfig.RateLimit.WalletBurst <= 0 {
+		c.ServerConfig.RateLimit.WalletBurst = max(1, int(c.ServerConfig.RateLimit.WalletRate))
+	}
+	if c.DbConfig.Driver == "" {
+		c.DbConfig.Driver = "postgres"
+	}
+	if c.DbConfig.Driver == "sqlite" && c.DbConfig.Path == "" {
+		c.DbConfig.Path = "wallet.db"
+	}
+	if c.DbConfig.MaxOpenConns == 0 {
+		c.DbConfig.MaxOpenConns = 25
+	}
+	if c.DbConfig.ReadTimeout == 0 {
+		c.DbConfig.ReadTimeout = duration(2 * time.Second)
+	}
+	if c.DbConfig.WriteTimeout == 0 {
+		c.DbConfig.WriteTimeout = duration(4 * time.Second)
+	}
+	if c.DbConfig.TxMaxRetries == 0 {
+		c.DbConfig.TxMaxRetries = 5
+	}
+	if c.DbConfig.TxRetryBaseDelay == 0 {
+		c.DbConfig.TxRetryBaseDelay = duration(10 * time.Millisecond)
+	}
+	if c.DbConfig.TxRetryMaxDelay == 0 {
+		c.DbConfig.TxRetryMaxDelay = duration(500 * time.Millisecond)
+	}
+	if c.LogConfig.Level == "" {
+		c.LogConfig.Level = "info"
+	}
+	if c.LogConfig.Format == "" {
+		c.LogConfig.Format = "json"
+	}
+	if c.Tracing.Exporter == "" {
+		c.Tracing.Exporter = "none"
+	}
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		c.Tracing.File = "traces.jsonl"
+	}
+	if c.Tracing.Endpoint == "" {
+		c.Tracing.Endpoint = "http://localhost:4318/v1/traces"
+	}
+	if c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "golang-server"
+	}
+	if c.Tracing.SampleRatio <= 0 || c.Tracing.SampleRatio > 1 {
+		c.Tracing.SampleRatio = 1
+	}
+	if len(c.Signing.Clients) > 0 && len(c.Signing.Paths) == 0 {
+		c.Signing.Paths = []string{"/api/send"}
+	}
+	if c.Auth.JWT.Leeway == 0 {
+		c.Auth.JWT.Leeway = duration(30 * time.Second)
+	}
+	if c.Signing.ClockSkew == 0 {
+		c.Signing.ClockSkew = duration(5 * time.Minute)
+	}
+	if c.Seed.Balance == "" {
+		c.Seed.Balance = "100.0"
+	}
 }
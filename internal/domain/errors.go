@@ -0,0 +1,51 @@
+package domain
+
+// Error — доменная ошибка с машиночитаемым кодом.
+// Используется слоями storage, service и api, чтобы клиенты могли
+// различать причины отказа без разбора сообщений СУБД.
+type Error struct {
+	Code    string // Стабильный машиночитаемый код, например "wallet_not_found"
+	Message string // Человекочитаемое описание
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError создаёт новую доменную ошибку.
+func newError(code, msg string) *Error {
+	return &Error{Code: code, Message: msg}
+}
+
+var (
+	// ErrWalletNotFound возвращается, если кошелёк с указанным ID не существует.
+	ErrWalletNotFound = newError("wallet_not_found", "wallet not found")
+	// ErrSameWallet возвращается при попытке перевода на тот же кошелёк.
+	ErrSameWallet = newError("same_wallet", "sender and recipient must be different wallets")
+	// ErrInsufficientFunds возвращается, если на балансе отправителя недостаточно средств.
+	ErrInsufficientFunds = newError("insufficient_funds", "insufficient funds")
+	// ErrWalletFrozen возвращается, если кошелёк заморожен и не может участвовать в переводах.
+	ErrWalletFrozen = newError("wallet_frozen", "wallet is frozen")
+	// ErrWalletClosed возвращается при операциях с закрытым кошельком.
+	ErrWalletClosed = newError("wallet_closed", "wallet is closed")
+	// ErrWalletNotEmpty возвращается при попытке закрыть кошелёк с ненулевым балансом.
+	ErrWalletNotEmpty = newError("wallet_not_empty", "wallet balance must be zero to close it")
+	// ErrInvalidStatusTransition возвращается при недопустимой смене статуса кошелька.
+	ErrInvalidStatusTransition = newError("invalid_status_transition", "wallet status transition is not allowed")
+	// ErrIdempotencyConflict возвращается, если Idempotency-Key уже использован с другим телом запроса.
+	ErrIdempotencyConflict = newError("idempotency_key_conflict", "idempotency key was already used with a different request")
+	// ErrUnauthorized возвращается, если запрос не содержит действующих учётных данных.
+	ErrUnauthorized = newError("unauthorized", "valid API key or token is required")
+	// ErrForbidden возвращается, если у вызывающего нет доступа к кошельку или операции.
+	ErrForbidden = newError("forbidden", "access denied")
+	// ErrAPIKeyNotFound возвращается, если ключ API с указанным ID не существует.
+	ErrAPIKeyNotFound = newError("api_key_not_found", "API key not found")
+	// ErrTransferLimitExceeded возвращается, если сумма перевода больше лимита на один перевод.
+	ErrTransferLimitExceeded = newError("transfer_limit_exceeded", "transfer amount exceeds the per-transfer limit")
+	// ErrDailyLimitExceeded возвращается, если перевод превысит дневной лимит исходящих переводов кошелька.
+	ErrDailyLimitExceeded = newError("daily_limit_exceeded", "daily outgoing limit exceeded")
+	// ErrMonthlyLimitExceeded возвращается, если перевод превысит месячный лимит исходящих переводов кошелька.
+	ErrMonthlyLimitExceeded = newError("monthly_limit_exceeded", "monthly outgoing limit exceeded")
+	// ErrHourlyCountExceeded возвращается, если с кошелька за последний час уже выполнено максимальное число переводов.
+	ErrHourlyCountExceeded = newError("hourly_transfer_count_exceeded", "hourly transfer count limit exceeded")
+)
@@ -0,0 +1,197 @@
+// Package fees рассчитывает комиссии за переводы по расписанию из конфигурации.
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/model"
+	"strings"
+)
+
+// maxPercent — максимальный процент комиссии.
+var maxPercent = model.MustParseMoney("100")
+
+// rate — комиссия в виде фиксированной части и процента от суммы перевода.
+type rate struct {
+	flat    model.Money
+	percent model.Money
+}
+
+// tier — ступень расписания для переводов до upTo включительно; nil upTo — без верхней границы.
+type tier struct {
+	upTo *model.Money
+	rate
+}
+
+// schedule — разобранное расписание комиссий.
+type schedule struct {
+	rate
+	tiers    []tier
+	min, max *model.Money
+}
+
+// fee рассчитывает комиссию за перевод amount.
+func (s schedule) fee(amount model.Money) model.Money {
+	r := s.rate
+	for _, t := range s.tiers {
+		if t.upTo == nil || amount.Cmp(*t.upTo) <= 0 {
+			r = t.rate
+			break
+		}
+	}
+
+	fee := r.flat.Add(amount.Percent(r.percent))
+	if s.min != nil && fee.Cmp(*s.min) < 0 {
+		fee = *s.min
+	}
+	if s.max != nil && fee.Cmp(*s.max) > 0 {
+		fee = *s.max
+	}
+	return fee
+}
+
+// Schedule хранит общее расписание комиссий, расписания отдельных кошельков-отправителей
+// и кошелёк, на который зачисляются комиссии.
+type Schedule struct {
+	wallet   uuid.UUID
+	defaults schedule
+	wallets  map[uuid.UUID]schedule
+}
+
+// NewSchedule разбирает расписание комиссий из конфигурации.
+// Кошелёк для комиссий обязателен, если хоть одно расписание задано.
+func NewSchedule(cfg config.FeesConfig) (*Schedule, error) {
+	s := &Schedule{wallets: make(map[uuid.UUID]schedule, len(cfg.Wallets))}
+
+	configured := len(cfg.Wallets) > 0 || !isEmpty(cfg.FeeScheduleConfig)
+	if cfg.CollectionWallet == "" {
+		if configured {
+			return nil, errors.New("collection_wallet is required when fees are configured")
+		}
+		return s, nil
+	}
+	wallet, err := uuid.Parse(cfg.CollectionWallet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collection_wallet: %w", err)
+	}
+	s.wallet = wallet
+
+	if s.defaults, err = parseSchedule(cfg.FeeScheduleConfig); err != nil {
+		return nil, err
+	}
+	for id, sc := range cfg.Wallets {
+		walletId, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("fees for wallet %q: invalid wallet id: %w", id, err)
+		}
+		if s.wallets[walletId], err = parseSchedule(sc); err != nil {
+			return nil, fmt.Errorf("fees for wallet %s: %w", walletId, err)
+		}
+	}
+	return s, nil
+}
+
+// Wallet возвращает кошелёк, на который зачисляются комиссии; uuid.Nil, если комиссии не настроены.
+func (s *Schedule) Wallet() uuid.UUID {
+	if s == nil {
+		return uuid.Nil
+	}
+	return s.wallet
+}
+
+// Quote рассчитывает комиссию за перевод amount с кошелька from.
+// Переводы с кошелька для комиссий комиссией не облагаются. Nil-Schedule комиссий не берёт.
+func (s *Schedule) Quote(from uuid.UUID, amount model.Money) model.Money {
+	if s == nil || s.wallet == uuid.Nil || from == s.wallet {
+		return model.Money{}
+	}
+	if sc, ok := s.wallets[from]; ok {
+		return sc.fee(amount)
+	}
+	return s.defaults.fee(amount)
+}
+
+// isEmpty сообщает, что расписание не задаёт комиссий.
+func isEmpty(cfg config.FeeScheduleConfig) bool {
+	return cfg.Flat == "" && cfg.Percent == "" && len(cfg.Tiers) == 0 && cfg.Min == "" && cfg.Max == ""
+}
+
+// parseSchedule разбирает расписание и проверяет его согласованность.
+func parseSchedule(cfg config.FeeScheduleConfig) (schedule, error) {
+	var s schedule
+	var err error
+	if s.rate, err = parseRate(cfg.Flat, cfg.Percent); err != nil {
+		return s, err
+	}
+	if len(cfg.Tiers) > 0 && (cfg.Flat != "" || cfg.Percent != "") {
+		return s, errors.New("flat and percent cannot be combined with tiers")
+	}
+
+	for i, tc := range cfg.Tiers {
+		var t tier
+		if t.rate, err = parseRate(tc.Flat, tc.Percent); err != nil {
+			return s, fmt.Errorf("tier %d: %w", i+1, err)
+		}
+		if t.upTo, err = parseOptional("up_to", tc.UpTo); err != nil {
+			return s, fmt.Errorf("tier %d: %w", i+1, err)
+		}
+		last := i == len(cfg.Tiers)-1
+		switch {
+		case t.upTo == nil && !last:
+			return s, fmt.Errorf("tier %d: only the last tier may omit up_to", i+1)
+		case t.upTo != nil && last:
+			return s, fmt.Errorf("tier %d: the last tier must omit up_to", i+1)
+		case t.upTo != nil && i > 0 && t.upTo.Cmp(*s.tiers[i-1].upTo) <= 0:
+			return s, fmt.Errorf("tier %d: up_to must increase", i+1)
+		}
+		s.tiers = append(s.tiers, t)
+	}
+
+	if s.min, err = parseOptional("min", cfg.Min); err != nil {
+		return s, err
+	}
+	if s.max, err = parseOptional("max", cfg.Max); err != nil {
+		return s, err
+	}
+	if s.min != nil && s.max != nil && s.min.Cmp(*s.max) > 0 {
+		return s, fmt.Errorf("min %s is greater than max %s", s.min, s.max)
+	}
+	return s, nil
+}
+
+// parseRate разбирает фиксированную часть и процент комиссии; пустые значения равны нулю.
+func parseRate(flat, percent string) (rate, error) {
+	var r rate
+	if p, err := parseOptional("flat", flat); err != nil {
+		return r, err
+	} else if p != nil {
+		r.flat = *p
+	}
+	if p, err := parseOptional("percent", percent); err != nil {
+		return r, err
+	} else if p != nil {
+		if p.Cmp(maxPercent) > 0 {
+			return r, fmt.Errorf("percent must not exceed 100, got %s", p)
+		}
+		r.percent = *p
+	}
+	return r, nil
+}
+
+// parseOptional разбирает неотрицательную сумму name; пустая строка даёт nil.
+func parseOptional(name, s string) (*model.Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	m, err := model.ParseMoney(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if m.Sign() < 0 {
+		return nil, fmt.Errorf("%s must not be negative, got %s", name, m)
+	}
+	return &m, nil
+}
@@ -0,0 +1,78 @@
+package fees
+
+import (
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/model"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	collection, tiered, free := uuid.New(), uuid.New(), uuid.New()
+	s, err := NewSchedule(config.FeesConfig{
+		CollectionWallet:  collection.String(),
+		FeeScheduleConfig: config.FeeScheduleConfig{Flat: "0.5", Percent: "1", Min: "1", Max: "10"},
+		Wallets: map[string]config.FeeScheduleConfig{
+			tiered.String(): {Tiers: []config.FeeTierConfig{
+				{UpTo: "100", Flat: "2"},
+				{UpTo: "1000", Percent: "0.5"},
+				{Percent: "0.1"},
+			}},
+			free.String(): {},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSchedule: %v", err)
+	}
+	if s.Wallet() != collection {
+		t.Errorf("Wallet = %s, want %s", s.Wallet(), collection)
+	}
+
+	other := uuid.New()
+	tests := []struct {
+		name   string
+		from   uuid.UUID
+		amount string
+		want   string
+	}{
+		{"flat and percent", other, "250", "3"},
+		{"minimum", other, "10", "1"},
+		{"maximum", other, "5000", "10"},
+		{"first tier", tiered, "100", "2"},
+		{"second tier", tiered, "100.01", "0.50005"},
+		{"unbounded tier", tiered, "5000", "5"},
+		{"empty wallet schedule", free, "5000", "0"},
+		{"collection wallet", collection, "5000", "0"},
+	}
+	for _, tt := range tests {
+		if got := s.Quote(tt.from, model.MustParseMoney(tt.amount)); got.Cmp(model.MustParseMoney(tt.want)) != 0 {
+			t.Errorf("%s: Quote(%s) = %s, want %s", tt.name, tt.amount, got, tt.want)
+		}
+	}
+
+	var disabled *Schedule
+	if fee := disabled.Quote(other, model.MustParseMoney("100")); !fee.IsZero() || disabled.Wallet() != uuid.Nil {
+		t.Errorf("nil Schedule charged %s", fee)
+	}
+}
+
+func TestNewScheduleRejectsInvalidConfig(t *testing.T) {
+	wallet := uuid.NewString()
+	invalid := []config.FeesConfig{
+		{FeeScheduleConfig: config.FeeScheduleConfig{Flat: "1"}},
+		{CollectionWallet: "not-a-uuid"},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Flat: "-1"}},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Percent: "100.5"}},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Min: "5", Max: "1"}},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Flat: "1", Tiers: []config.FeeTierConfig{{Flat: "1"}}}},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Tiers: []config.FeeTierConfig{{UpTo: "10"}}}},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Tiers: []config.FeeTierConfig{{}, {Flat: "1"}}}},
+		{CollectionWallet: wallet, FeeScheduleConfig: config.FeeScheduleConfig{Tiers: []config.FeeTierConfig{{UpTo: "10"}, {UpTo: "10"}, {}}}},
+		{CollectionWallet: wallet, Wallets: map[string]config.FeeScheduleConfig{"bad": {}}},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewSchedule(cfg); err == nil {
+			t.Errorf("NewSchedule(%+v) succeeded, want error", cfg)
+		}
+	}
+}
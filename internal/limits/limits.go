@@ -0,0 +1,181 @@
+// Package limits проверяет лимиты исходящих переводов: сумму одного перевода,
+// суммы переводов за сутки и месяц и число переводов за час.
+package limits
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"strings"
+	"time"
+)
+
+// Unlimited — значение лимита в переопределении кошелька, отключающее общий лимит.
+const Unlimited = "unlimited"
+
+// Engine хранит общие лимиты и лимиты кошельков с переопределениями,
+// уже объединёнными с общими.
+type Engine struct {
+	defaults model.TransferLimits
+	wallets  map[uuid.UUID]model.TransferLimits
+}
+
+// NewEngine разбирает лимиты из конфигурации.
+// Возвращает ошибку, если сумма не разбирается или лимит не положителен.
+func NewEngine(cfg config.LimitsConfig) (*Engine, error) {
+	defaults, err := parseLimits(cfg.TransferLimitsConfig, model.TransferLimits{}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{defaults: defaults, wallets: make(map[uuid.UUID]model.TransferLimits, len(cfg.Wallets))}
+	for id, override := range cfg.Wallets {
+		walletId, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("limits for wallet %q: invalid wallet id: %w", id, err)
+		}
+		l, err := parseLimits(override, defaults, true)
+		if err != nil {
+			return nil, fmt.Errorf("limits for wallet %s: %w", walletId, err)
+		}
+		e.wallets[walletId] = l
+	}
+	return e, nil
+}
+
+// For возвращает лимиты исходящих переводов кошелька. Nil-Engine лимитов не задаёт.
+func (e *Engine) For(walletId uuid.UUID) *model.TransferLimits {
+	if e == nil {
+		return nil
+	}
+	if l, ok := e.wallets[walletId]; ok {
+		return &l
+	}
+	l := e.defaults
+	return &l
+}
+
+// parseLimits разбирает лимиты. В переопределении (override) пустое значение
+// оставляет лимит из inherit, а "unlimited" и -1 отключают его.
+func parseLimits(cfg config.TransferLimitsConfig, inherit model.TransferLimits, override bool) (model.TransferLimits, error) {
+	var l model.TransferLimits
+	var err error
+	if l.MaxTransfer, err = parseMoney("max_transfer", cfg.MaxTransfer, inherit.MaxTransfer, override); err != nil {
+		return l, err
+	}
+	if l.DailyOutgoing, err = parseMoney("daily_outgoing", cfg.DailyOutgoing, inherit.DailyOutgoing, override); err != nil {
+		return l, err
+	}
+	if l.MonthlyOutgoing, err = parseMoney("monthly_outgoing", cfg.MonthlyOutgoing, inherit.MonthlyOutgoing, override); err != nil {
+		return l, err
+	}
+
+	switch {
+	case cfg.HourlyCount == 0:
+		l.HourlyCount = inherit.HourlyCount
+	case cfg.HourlyCount == -1 && override:
+		l.HourlyCount = nil
+	case cfg.HourlyCount > 0:
+		n := cfg.HourlyCount
+		l.HourlyCount = &n
+	default:
+		return l, fmt.Errorf("hourly_count must be positive, got %d", cfg.HourlyCount)
+	}
+	return l, nil
+}
+
+// parseMoney разбирает денежный лимит name.
+func parseMoney(name, s string, inherit *model.Money, override bool) (*model.Money, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return inherit, nil
+	case override && (s == Unlimited || s == "-1"):
+		return nil, nil
+	}
+
+	m, err := model.ParseMoney(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if m.Sign() <= 0 {
+		return nil, fmt.Errorf("%s must be positive, got %s", name, m)
+	}
+	return &m, nil
+}
+
+// NeedsUsage сообщает, нужны ли для проверки лимитов l переводы кошелька за прошлые периоды.
+func NeedsUsage(l *model.TransferLimits) bool {
+	return l != nil && (l.DailyOutgoing != nil || l.MonthlyOutgoing != nil || l.HourlyCount != nil)
+}
+
+// CheckAmount проверяет сумму одного перевода.
+// Возвращает domain.ErrTransferLimitExceeded, если сумма больше лимита.
+func CheckAmount(l *model.TransferLimits, amount model.Money) error {
+	if l != nil && l.MaxTransfer != nil && amount.Cmp(*l.MaxTransfer) > 0 {
+		return fmt.Errorf("%w: limit is %s", domain.ErrTransferLimitExceeded, *l.MaxTransfer)
+	}
+	return nil
+}
+
+// Check проверяет перевод amount по всем лимитам l, учитывая переводы usage за прошлые периоды.
+// Возвращает доменную ошибку первого нарушенного лимита; nil l означает отсутствие лимитов.
+func Check(l *model.TransferLimits, amount model.Money, usage model.OutgoingUsage) error {
+	if l == nil {
+		return nil
+	}
+	if err := CheckAmount(l, amount); err != nil {
+		return err
+	}
+	if l.HourlyCount != nil && usage.HourCount >= *l.HourlyCount {
+		return fmt.Errorf("%w: limit is %d transfers", domain.ErrHourlyCountExceeded, *l.HourlyCount)
+	}
+	if l.DailyOutgoing != nil && usage.Daily.Add(amount).Cmp(*l.DailyOutgoing) > 0 {
+		return fmt.Errorf("%w: limit is %s, already sent %s", domain.ErrDailyLimitExceeded, *l.DailyOutgoing, usage.Daily)
+	}
+	if l.MonthlyOutgoing != nil && usage.Monthly.Add(amount).Cmp(*l.MonthlyOutgoing) > 0 {
+		return fmt.Errorf("%w: limit is %s, already sent %s", domain.ErrMonthlyLimitExceeded, *l.MonthlyOutgoing, usage.Monthly)
+	}
+	return nil
+}
+
+// Windows — начала периодов лимитов: календарных суток и месяца UTC и последнего часа.
+type Windows struct {
+	Day   time.Time
+	Month time.Time
+	Hour  time.Time
+}
+
+// WindowsAt возвращает периоды лимитов для перевода в момент now.
+func WindowsAt(now time.Time) Windows {
+	now = now.UTC()
+	year, month, day := now.Date()
+	return Windows{
+		Day:   time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+		Month: time.Date(year, month, 1, 0, 0, 0, 0, time.UTC),
+		Hour:  now.Add(-time.Hour),
+	}
+}
+
+// Since возвращает начало самого раннего периода: переводы до него на лимиты не влияют.
+func (w Windows) Since() time.Time {
+	if w.Hour.Before(w.Month) {
+		return w.Hour
+	}
+	return w.Month
+}
+
+// Add учитывает в usage перевод amount, выполненный в момент at.
+func (w Windows) Add(usage *model.OutgoingUsage, amount model.Money, at time.Time) {
+	if !at.Before(w.Month) {
+		usage.Monthly = usage.Monthly.Add(amount)
+	}
+	if !at.Before(w.Day) {
+		usage.Daily = usage.Daily.Add(amount)
+	}
+	if !at.Before(w.Hour) {
+		usage.HourCount++
+	}
+}
@@ -0,0 +1,109 @@
+package limits
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"testing"
+	"time"
+)
+
+func TestEngineOverrides(t *testing.T) {
+	inherit, unlimited, custom := uuid.New(), uuid.New(), uuid.New()
+	e, err := NewEngine(config.LimitsConfig{
+		TransferLimitsConfig: config.TransferLimitsConfig{MaxTransfer: "100", DailyOutgoing: "500", HourlyCount: 10},
+		Wallets: map[string]config.TransferLimitsConfig{
+			inherit.String():   {},
+			unlimited.String(): {MaxTransfer: Unlimited, DailyOutgoing: "-1", HourlyCount: -1},
+			custom.String():    {MaxTransfer: "1000", MonthlyOutgoing: "5000"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	for _, id := range []uuid.UUID{uuid.New(), inherit} {
+		l := e.For(id)
+		if l.MaxTransfer.Cmp(model.MustParseMoney("100")) != 0 || l.DailyOutgoing.Cmp(model.MustParseMoney("500")) != 0 || l.MonthlyOutgoing != nil || *l.HourlyCount != 10 {
+			t.Errorf("For(%s) = %+v, want the global limits", id, l)
+		}
+	}
+	if l := e.For(unlimited); l.MaxTransfer != nil || l.DailyOutgoing != nil || l.HourlyCount != nil || NeedsUsage(l) {
+		t.Errorf("For(unlimited) = %+v, want no limits", l)
+	}
+	l := e.For(custom)
+	if l.MaxTransfer.Cmp(model.MustParseMoney("1000")) != 0 || l.DailyOutgoing.Cmp(model.MustParseMoney("500")) != 0 || l.MonthlyOutgoing.Cmp(model.MustParseMoney("5000")) != 0 || *l.HourlyCount != 10 {
+		t.Errorf("For(custom) = %+v, want overridden limits on top of the global ones", l)
+	}
+
+	var nilEngine *Engine
+	if l := nilEngine.For(custom); l != nil {
+		t.Errorf("nil Engine returned limits %+v", l)
+	}
+}
+
+func TestEngineRejectsInvalidConfig(t *testing.T) {
+	invalid := []config.LimitsConfig{
+		{TransferLimitsConfig: config.TransferLimitsConfig{MaxTransfer: "abc"}},
+		{TransferLimitsConfig: config.TransferLimitsConfig{DailyOutgoing: "0"}},
+		{TransferLimitsConfig: config.TransferLimitsConfig{MonthlyOutgoing: Unlimited}},
+		{TransferLimitsConfig: config.TransferLimitsConfig{HourlyCount: -1}},
+		{Wallets: map[string]config.TransferLimitsConfig{"not-a-uuid": {MaxTransfer: "1"}}},
+		{Wallets: map[string]config.TransferLimitsConfig{uuid.NewString(): {HourlyCount: -2}}},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewEngine(cfg); err == nil {
+			t.Errorf("NewEngine(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	maxTransfer, daily, monthly, hourly := model.MustParseMoney("100"), model.MustParseMoney("300"), model.MustParseMoney("1000"), 3
+	l := &model.TransferLimits{MaxTransfer: &maxTransfer, DailyOutgoing: &daily, MonthlyOutgoing: &monthly, HourlyCount: &hourly}
+
+	tests := []struct {
+		name   string
+		amount string
+		usage  model.OutgoingUsage
+		want   error
+	}{
+		{"within limits", "100", model.OutgoingUsage{Daily: model.MustParseMoney("200"), Monthly: model.MustParseMoney("900"), HourCount: 2}, nil},
+		{"per-transfer", "100.01", model.OutgoingUsage{}, domain.ErrTransferLimitExceeded},
+		{"hourly count", "1", model.OutgoingUsage{HourCount: 3}, domain.ErrHourlyCountExceeded},
+		{"daily", "100", model.OutgoingUsage{Daily: model.MustParseMoney("200.01"), Monthly: model.MustParseMoney("200.01")}, domain.ErrDailyLimitExceeded},
+		{"monthly", "50", model.OutgoingUsage{Daily: model.MustParseMoney("50"), Monthly: model.MustParseMoney("950.5")}, domain.ErrMonthlyLimitExceeded},
+	}
+	for _, tt := range tests {
+		err := Check(l, model.MustParseMoney(tt.amount), tt.usage)
+		if !errors.Is(err, tt.want) || (tt.want == nil) != (err == nil) {
+			t.Errorf("%s: Check = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if err := Check(nil, model.MustParseMoney("1000000"), model.OutgoingUsage{HourCount: 1000}); err != nil {
+		t.Errorf("Check without limits = %v", err)
+	}
+}
+
+func TestWindows(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC)
+	w := WindowsAt(now.In(time.FixedZone("UTC+3", 3*60*60)))
+	if !w.Day.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || !w.Month.Equal(w.Day) || !w.Hour.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("WindowsAt(%s) = %+v", now, w)
+	}
+	if !w.Since().Equal(w.Hour) {
+		t.Errorf("Since = %s, want the start of the last hour", w.Since())
+	}
+
+	var usage model.OutgoingUsage
+	w.Add(&usage, model.MustParseMoney("1"), now.Add(-45*time.Minute))  // Прошлые сутки и месяц, последний час
+	w.Add(&usage, model.MustParseMoney("10"), now.Add(-10*time.Minute)) // Текущие сутки
+	w.Add(&usage, model.MustParseMoney("100"), now.Add(-2*time.Hour))   // Вне всех периодов
+	want := model.OutgoingUsage{Daily: model.MustParseMoney("10"), Monthly: model.MustParseMoney("10"), HourCount: 2}
+	if usage.Daily.Cmp(want.Daily) != 0 || usage.Monthly.Cmp(want.Monthly) != 0 || usage.HourCount != want.HourCount {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+}
@@ -0,0 +1,117 @@
+// Package logging настраивает структурированный лог на основе log/slog
+// и переносит атрибуты запроса (идентификатор, кошельки перевода и т.п.) через контекст,
+// чтобы их получали все записи, сделанные в рамках запроса.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"golang-server/internal/config"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// New создаёт логгер с уровнем и форматом из конфигурации.
+// Параметры:
+//   - cfg: уровень (debug, info, warn, error) и формат (json, text).
+//   - w: куда писать записи, обычно os.Stderr.
+//
+// Возвращает:
+//   - *slog.Logger: логгер, добавляющий к записям атрибуты запроса из контекста.
+//   - error: ошибку, если уровень или формат неизвестны.
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler добавляет к каждой записи атрибуты запроса из контекста.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if attrs := Attrs(ctx); len(attrs) > 0 {
+			r = r.Clone()
+			r.AddAttrs(attrs...)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// contextKey — ключи значений пакета в контексте.
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	attrsKey
+)
+
+// requestAttrs — атрибуты, накопленные за время обработки запроса.
+// Дополняются по мере обработки, поэтому хранятся по указателю под мьютексом.
+type requestAttrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithRequestID возвращает контекст запроса с идентификатором id.
+// Идентификатор добавляется ко всем записям лога, сделанным с этим контекстом,
+// а AddAttrs начинает накапливать атрибуты этого запроса.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, id)
+	return context.WithValue(ctx, attrsKey, &requestAttrs{attrs: []slog.Attr{slog.String("request_id", id)}})
+}
+
+// RequestID возвращает идентификатор запроса или пустую строку, если его нет в контексте.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// AddAttrs добавляет атрибуты ко всем последующим записям лога запроса,
+// в том числе к итоговой записи LoggingMiddleware. Вне запроса ничего не делает.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	ra, ok := ctx.Value(attrsKey).(*requestAttrs)
+	if !ok {
+		return
+	}
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	ra.attrs = append(ra.attrs, attrs...)
+}
+
+// Attrs возвращает копию атрибутов запроса из контекста.
+func Attrs(ctx context.Context) []slog.Attr {
+	ra, ok := ctx.Value(attrsKey).(*requestAttrs)
+	if !ok {
+		return nil
+	}
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	return slices.Clone(ra.attrs)
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"golang-server/internal/config"
+	"log/slog"
+	"testing"
+)
+
+func TestNewErrors(t *testing.T) {
+	tests := []config.LogConfig{
+		{Level: "verbose", Format: "json"},
+		{Level: "info", Format: "xml"},
+	}
+	for _, cfg := range tests {
+		if _, err := New(cfg, &bytes.Buffer{}); err == nil {
+			t.Errorf("New(%+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestRequestAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(config.LogConfig{Level: "info", Format: "json"}, &buf)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := WithRequestID(t.Context(), "req-1")
+	AddAttrs(ctx, slog.String("from_wallet", "a"))
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "done", slog.Int("status", 200))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{"msg": "done", "request_id": "req-1", "from_wallet": "a", "status": float64(200)}
+	for k, v := range want {
+		if record[k] != v {
+			t.Errorf("record[%q] = %v, want %v", k, record[k], v)
+		}
+	}
+
+	if id := RequestID(ctx); id != "req-1" {
+		t.Errorf("RequestID = %q, want req-1", id)
+	}
+	// Вне запроса атрибуты не накапливаются
+	AddAttrs(t.Context(), slog.String("ignored", "x"))
+	if attrs := Attrs(t.Context()); attrs != nil {
+		t.Errorf("Attrs outside a request = %v, want nil", attrs)
+	}
+}
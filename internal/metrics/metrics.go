@@ -0,0 +1,276 @@
+// Package metrics — минимальная реализация метрик в текстовом формате экспозиции Prometheus
+// (https://prometheus.io/docs/instrumenting/exposition_formats/) без внешних зависимостей.
+// Поддерживаются счётчики и гистограммы с метками, а также счётчики и датчики,
+// значения которых вычисляются функцией в момент сбора.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Типы метрик в строке # TYPE.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefaultBuckets — границы гистограммы длительности в секундах, как в клиенте Prometheus.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default — реестр процесса, который отдаётся по /metrics.
+var Default = NewRegistry()
+
+// collector — метрика, которую реестр выводит при сборе.
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry хранит зарегистрированные метрики и выводит их в текстовом формате.
+type Registry struct {
+	mu         sync.Mutex
+	names      map[string]bool
+	collectors []collector
+}
+
+// NewRegistry создаёт пустой реестр.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register добавляет метрику в реестр. Повторная регистрация имени — ошибка программиста.
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.names[name] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText выводит все метрики реестра в текстовом формате Prometheus.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler возвращает обработчик, отдающий метрики реестра.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			slog.Warn("failed to write metrics", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// CounterVec — монотонно растущий счётчик с набором меток.
+type CounterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*series
+}
+
+// series — значение одной комбинации меток.
+type series struct {
+	labelValues []string
+	value       float64
+	// Только для гистограмм
+	buckets []uint64
+	count   uint64
+}
+
+// NewCounterVec регистрирует счётчик с метками labels.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]*series)}
+	r.register(name, c)
+	return c
+}
+
+// Inc увеличивает счётчик на единицу.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счётчик на v; отрицательные значения игнорируются.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	getSeries(c.values, c.name, c.labels, labelValues, nil).value += v
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, typeCounter)
+	for _, s := range sortedSeries(c.values) {
+		writeSample(w, c.name, c.labels, s.labelValues, "", "", s.value)
+	}
+}
+
+// HistogramVec — гистограмма с фиксированными границами и набором меток.
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	values     map[string]*series
+}
+
+// NewHistogramVec регистрирует гистограмму с границами buckets (по возрастанию) и метками labels.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*series)}
+	r.register(name, h)
+	return h
+}
+
+// Observe добавляет наблюдение v.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := getSeries(h.values, h.name, h.labels, labelValues, h.buckets)
+	s.value += v
+	s.count++
+	// Бакеты хранятся некумулятивно, накопленные значения считаются при выводе
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		s.buckets[i]++
+	}
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, typeHistogram)
+	for _, s := range sortedSeries(h.values) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.buckets[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, "", "", s.value)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// funcMetric — метрика без меток, значение которой вычисляется при сборе.
+type funcMetric struct {
+	name, help, typ string
+	fn              func() float64
+}
+
+// NewGaugeFunc регистрирует датчик, значение которого возвращает fn.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, &funcMetric{name: name, help: help, typ: typeGauge, fn: fn})
+}
+
+// NewCounterFunc регистрирует счётчик, значение которого возвращает fn
+// (например, накопительный счётчик, который ведёт другой пакет).
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(name, &funcMetric{name: name, help: help, typ: typeCounter, fn: fn})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	writeHeader(w, m.name, m.help, m.typ)
+	writeSample(w, m.name, nil, nil, "", "", m.fn())
+}
+
+// getSeries возвращает серию для значений меток, создавая её при первом обращении.
+func getSeries(values map[string]*series, name string, labels, labelValues []string, buckets []float64) *series {
+	if len(labelValues) != len(labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := values[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		if buckets != nil {
+			s.buckets = make([]uint64, len(buckets))
+		}
+		values[key] = s
+	}
+	return s
+}
+
+// sortedSeries возвращает серии в порядке значений меток, чтобы вывод был стабильным.
+func sortedSeries(values map[string]*series) []*series {
+	result := make([]*series, 0, len(values))
+	for _, s := range values {
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b *series) int { return slices.Compare(a.labelValues, b.labelValues) })
+	return result
+}
+
+// writeHeader выводит строки # HELP и # TYPE.
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// labelEscaper экранирует значение метки.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSample выводит одну строку значения. extraName и extraValue добавляют
+// дополнительную метку (le у бакетов гистограммы).
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraName, extraValue string, value float64) {
+	_, _ = w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		_ = w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				_ = w.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(w, `%s="%s"`, label, labelEscaper.Replace(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				_ = w.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(w, `%s="%s"`, extraName, extraValue)
+		}
+		_ = w.WriteByte('}')
+	}
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(formatFloat(value))
+	_ = w.WriteByte('\n')
+}
+
+// formatFloat форматирует число так, как его ожидает Prometheus.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("requests_total", "Total requests.\nSecond line.", "route")
+	duration := r.NewHistogramVec("duration_seconds", "Request duration.", []float64{0.1, 1}, "route")
+	r.NewGaugeFunc("queue_size", "Queue size.", func() float64 { return 3 })
+
+	requests.Inc(`/b"`)
+	requests.Add(2, "/a")
+	requests.Add(-1, "/a")
+	duration.Observe(0.05, "/a")
+	duration.Observe(0.1, "/a")
+	duration.Observe(0.5, "/a")
+	duration.Observe(7, "/a")
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+
+	want := `# HELP requests_total Total requests.\nSecond line.
+# TYPE requests_total counter
+requests_total{route="/a"} 2
+requests_total{route="/b\""} 1
+# HELP duration_seconds Request duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="/a",le="0.1"} 2
+duration_seconds_bucket{route="/a",le="1"} 3
+duration_seconds_bucket{route="/a",le="+Inf"} 4
+duration_seconds_sum{route="/a"} 7.65
+duration_seconds_count{route="/a"} 4
+# HELP queue_size Queue size.
+# TYPE queue_size gauge
+queue_size 3
+`
+	if got := sb.String(); got != want {
+		t.Errorf("WriteText output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "First.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate metric did not panic")
+		}
+	}()
+	r.NewGaugeFunc("dup_total", "Second.", func() float64 { return 0 })
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterFunc("ticks_total", "Ticks.", func() float64 { return 42 })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "ticks_total 42\n") {
+		t.Errorf("body = %q, want ticks_total 42", rec.Body.String())
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// TransactionDirection — направление переводов относительно кошелька.
+type TransactionDirection string
+
+const (
+	DirectionAll      TransactionDirection = "all"      // Входящие и исходящие
+	DirectionIncoming TransactionDirection = "incoming" // Кошелёк — получатель
+	DirectionOutgoing TransactionDirection = "outgoing" // Кошелёк — отправитель
+)
+
+// Valid сообщает, является ли направление одним из известных значений.
+func (d TransactionDirection) Valid() bool {
+	switch d {
+	case DirectionAll, DirectionIncoming, DirectionOutgoing:
+		return true
+	}
+	return false
+}
+
+// TransactionFilter задаёт выборку истории переводов кошелька.
+// Nil-поля означают отсутствие ограничения.
+type TransactionFilter struct {
+	WalletId  uuid.UUID
+	Direction TransactionDirection
+	FromDate  *time.Time // Включительно
+	ToDate    *time.Time // Не включительно
+	MinAmount *Money     // Включительно
+	MaxAmount *Money     // Включительно
+	Limit     int
+	Cursor    *TransactionCursor // Позиция, после которой продолжается выборка
+}
+
+// TransactionCursor — позиция в истории переводов, упорядоченной по (transfer_date, id) по убыванию.
+type TransactionCursor struct {
+	TransferDate time.Time
+	Id           uuid.UUID
+}
+
+// Encode возвращает непрозрачное строковое представление курсора для клиента.
+func (c TransactionCursor) Encode() string {
+	raw := c.TransferDate.UTC().Format(time.RFC3339Nano) + "|" + c.Id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseTransactionCursor разбирает курсор, полученный от TransactionCursor.Encode.
+func ParseTransactionCursor(s string) (TransactionCursor, error) {
+	errInvalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return TransactionCursor{}, errInvalid
+	}
+	dateStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return TransactionCursor{}, errInvalid
+	}
+	date, err := time.Parse(time.RFC3339Nano, dateStr)
+	if err != nil {
+		return TransactionCursor{}, errInvalid
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return TransactionCursor{}, errInvalid
+	}
+	return TransactionCursor{TransferDate: date, Id: id}, nil
+}
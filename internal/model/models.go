@@ -1,21 +1,128 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
-	"math/big"
 	"time"
 )
 
+// WalletStatus — состояние жизненного цикла кошелька.
+type WalletStatus string
+
+const (
+	WalletActive WalletStatus = "active" // Кошелёк участвует в переводах
+	WalletFrozen WalletStatus = "frozen" // Кошелёк временно заблокирован
+	WalletClosed WalletStatus = "closed" // Кошелёк закрыт окончательно
+)
+
+// Valid сообщает, является ли статус одним из известных значений.
+func (s WalletStatus) Valid() bool {
+	switch s {
+	case WalletActive, WalletFrozen, WalletClosed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo сообщает, допустим ли переход из статуса s в next.
+// Активный и замороженный кошельки переходят друг в друга и могут быть закрыты;
+// закрытый кошелёк изменить нельзя.
+func (s WalletStatus) CanTransitionTo(next WalletStatus) bool {
+	switch s {
+	case WalletActive:
+		return next == WalletFrozen || next == WalletClosed
+	case WalletFrozen:
+		return next == WalletActive || next == WalletClosed
+	}
+	return false
+}
+
+// WalletStatusChange — запись истории смены статуса кошелька.
+type WalletStatusChange struct {
+	Id        uuid.UUID    `json:"id"`
+	WalletId  uuid.UUID    `json:"wallet_id"`
+	OldStatus WalletStatus `json:"old_status"`
+	NewStatus WalletStatus `json:"new_status"`
+	Reason    string       `json:"reason"`
+	Actor     string       `json:"actor"`
+	ChangedAt time.Time    `json:"changed_at"`
+}
+
+// TransferLimits — лимиты исходящих переводов кошелька. nil в поле — без ограничения.
+type TransferLimits struct {
+	MaxTransfer     *Money // Сумма одного перевода
+	DailyOutgoing   *Money // Сумма переводов за календарные сутки UTC
+	MonthlyOutgoing *Money // Сумма переводов за календарный месяц UTC
+	HourlyCount     *int   // Число переводов за последний час
+}
+
+// OutgoingUsage — исходящие переводы кошелька в окнах лимитов до текущего перевода.
+type OutgoingUsage struct {
+	Daily     Money
+	Monthly   Money
+	HourCount int
+}
+
+// APIKeyRole — роль ключа API.
+type APIKeyRole string
+
+const (
+	APIKeyClient APIKeyRole = "client" // Доступ только к привязанным кошелькам
+	APIKeyAdmin  APIKeyRole = "admin"  // Доступ ко всем кошелькам и административным операциям
+)
+
+// Valid сообщает, является ли роль одним из известных значений.
+func (r APIKeyRole) Valid() bool {
+	return r == APIKeyClient || r == APIKeyAdmin
+}
+
+// APIKey — ключ API. Сам ключ не хранится: по нему вычисляется SHA-256 хеш,
+// и поиск при аутентификации выполняется по хешу.
+type APIKey struct {
+	Id        uuid.UUID   `json:"id"`
+	Name      string      `json:"name"`
+	Hash      string      `json:"-"`
+	Role      APIKeyRole  `json:"role"`
+	Wallets   []uuid.UUID `json:"wallets"` // Кошельки, доступные ключу с ролью client
+	CreatedAt time.Time   `json:"created_at"`
+	RevokedAt *time.Time  `json:"revoked_at,omitempty"`
+}
+
 type Wallet struct {
-	Id         uuid.UUID `json:"id"`
-	Balance    big.Float `json:"balance"`
-	DateUpdate time.Time `json:"date_update"`
+	Id         uuid.UUID       `json:"id"`
+	Balance    Money           `json:"balance"`
+	Status     WalletStatus    `json:"status"`
+	OwnerRef   *string         `json:"owner_ref,omitempty"`
+	Metadata   json.RawMessage `json:"metadata"`
+	CreatedAt  time.Time       `json:"created_at"`
+	DateUpdate time.Time       `json:"date_update"`
 }
 
 type Transaction struct {
 	Id           uuid.UUID `json:"id"`
 	From         uuid.UUID `json:"from"`
 	To           uuid.UUID `json:"to"`
-	Amount       big.Float `json:"amount"`
+	Amount       Money     `json:"amount"`
+	Fee          Money     `json:"fee"`
 	TransferDate time.Time `json:"transfer_date"`
+	// Replayed — транзакция возвращена повтором запроса с тем же ключом идемпотентности, перевод не выполнялся.
+	Replayed bool `json:"-"`
+}
+
+// LedgerEntryType — вид проводки в журнале двойной записи.
+type LedgerEntryType string
+
+const (
+	LedgerDebit   LedgerEntryType = "debit"   // Списание с кошелька, сумма отрицательная
+	LedgerCredit  LedgerEntryType = "credit"  // Зачисление на кошелёк, сумма положительная
+	LedgerOpening LedgerEntryType = "opening" // Начальный баланс кошелька
+)
+
+type LedgerEntry struct {
+	Id            uuid.UUID       `json:"id"`
+	TransactionId *uuid.UUID      `json:"transaction_id,omitempty"`
+	WalletId      uuid.UUID       `json:"wallet_id"`
+	Type          LedgerEntryType `json:"type"`
+	Amount        Money           `json:"amount"`
+	CreatedAt     time.Time       `json:"created_at"`
 }
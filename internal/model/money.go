@@ -0,0 +1,221 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MoneyScale — число знаков после запятой, с которым хранятся денежные суммы.
+const MoneyScale = 8
+
+// MoneyIntDigits — максимальное число цифр целой части: суммы хранятся в NUMERIC(38, 8).
+const MoneyIntDigits = 38 - MoneyScale
+
+// moneyFactor = 10^MoneyScale.
+var moneyFactor = new(big.Int).Exp(big.NewInt(10), big.NewInt(MoneyScale), nil)
+
+// Money — точная десятичная денежная сумма с фиксированной точностью MoneyScale.
+// Хранится как целое число минимальных единиц (value * 10^MoneyScale),
+// поэтому сложение и сравнение выполняются без ошибок округления.
+// Нулевое значение Money равно нулю.
+type Money struct {
+	units *big.Int
+}
+
+// ParseMoney разбирает десятичную строку вида "-123.45".
+// Экспоненциальная запись не поддерживается, дробная часть не может быть длиннее MoneyScale,
+// а целая часть без незначащих нулей — длиннее MoneyIntDigits.
+func ParseMoney(s string) (Money, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		neg = true
+		str = str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(str, ".")
+	if intPart == "" || hasDot && fracPart == "" {
+		return Money{}, fmt.Errorf("invalid money value %q", s)
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, fmt.Errorf("invalid money value %q", s)
+	}
+	// Незначащие нули (например, из NUMERIC без явной точности) не влияют на значение
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > MoneyScale {
+		return Money{}, fmt.Errorf("invalid money value %q: more than %d decimal places", s, MoneyScale)
+	}
+	if len(strings.TrimLeft(intPart, "0")) > MoneyIntDigits {
+		return Money{}, fmt.Errorf("invalid money value %q: more than %d integer digits", s, MoneyIntDigits)
+	}
+
+	digits := intPart + fracPart + strings.Repeat("0", MoneyScale-len(fracPart))
+	units, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid money value %q", s)
+	}
+	if neg {
+		units.Neg(units)
+	}
+	return Money{units: units}, nil
+}
+
+// MustParseMoney аналогичен ParseMoney, но паникует при ошибке.
+// Предназначен для констант в коде.
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// isDigits сообщает, состоит ли строка только из десятичных цифр.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// int возвращает внутреннее представление; для нулевого значения — 0.
+func (m Money) int() *big.Int {
+	if m.units == nil {
+		return new(big.Int)
+	}
+	return m.units
+}
+
+// Add возвращает m + other.
+func (m Money) Add(other Money) Money {
+	return Money{units: new(big.Int).Add(m.int(), other.int())}
+}
+
+// Sub возвращает m - other.
+func (m Money) Sub(other Money) Money {
+	return Money{units: new(big.Int).Sub(m.int(), other.int())}
+}
+
+// Neg возвращает -m.
+func (m Money) Neg() Money {
+	return Money{units: new(big.Int).Neg(m.int())}
+}
+
+// Percent возвращает percent процентов от m, округлённые до MoneyScale знаков
+// по правилу половина от нуля.
+func (m Money) Percent(percent Money) Money {
+	// m.units * percent.units / (100 * 10^MoneyScale) с округлением
+	num := new(big.Int).Mul(m.int(), percent.int())
+	den := new(big.Int).Mul(big.NewInt(100), moneyFactor)
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	return Money{units: q}
+}
+
+// Cmp сравнивает m и other: -1, если m < other, 0, если равны, +1, если m > other.
+func (m Money) Cmp(other Money) int {
+	return m.int().Cmp(other.int())
+}
+
+// Sign возвращает -1, 0 или +1 в зависимости от знака суммы.
+func (m Money) Sign() int {
+	return m.int().Sign()
+}
+
+// IsZero сообщает, равна ли сумма нулю.
+func (m Money) IsZero() bool {
+	return m.Sign() == 0
+}
+
+// String возвращает десятичное представление без завершающих нулей дробной части, например "100" или "3.5".
+func (m Money) String() string {
+	units := m.int()
+	abs := new(big.Int).Abs(units)
+	q, r := new(big.Int).QuoRem(abs, moneyFactor, new(big.Int))
+
+	var b strings.Builder
+	if units.Sign() < 0 {
+		b.WriteByte('-')
+	}
+	b.WriteString(q.String())
+	if r.Sign() != 0 {
+		frac := r.String()
+		frac = strings.Repeat("0", MoneyScale-len(frac)) + frac
+		b.WriteByte('.')
+		b.WriteString(strings.TrimRight(frac, "0"))
+	}
+	return b.String()
+}
+
+// Float64 возвращает приближённое значение суммы. Только для метрик и отчётов,
+// не для расчётов.
+func (m Money) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(m.int(), moneyFactor).Float64()
+	return f
+}
+
+// MarshalJSON сериализует сумму в JSON-строку, чтобы клиенты не теряли точность.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON принимает сумму как JSON-строку ("3.50") или как JSON-число (3.50).
+// Число разбирается из исходного текста, без промежуточного float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+	parsed, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan реализует sql.Scanner для чтения NUMERIC-столбцов.
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = Money{units: new(big.Int).Mul(big.NewInt(v), moneyFactor)}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value реализует driver.Valuer: сумма передаётся в базу строкой,
+// которую СУБД приводит к NUMERIC без потери точности.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	maxInt := strings.Repeat("9", MoneyIntDigits)
+	valid := []struct{ in, want string }{
+		{"0", "0"},
+		{"-0", "0"},
+		{"+3.5", "3.5"},
+		{"-123.45", "-123.45"},
+		{" 100 ", "100"},
+		{"007.10000000000", "7.1"}, // Незначащие нули не учитываются в точности
+		{"0.00000001", "0.00000001"},
+		{maxInt + ".99999999", maxInt + ".99999999"},
+		{"000" + maxInt, maxInt},
+	}
+	for _, tt := range valid {
+		m, err := ParseMoney(tt.in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", tt.in, err)
+			continue
+		}
+		if got := m.String(); got != tt.want {
+			t.Errorf("ParseMoney(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	invalid := []string{
+		"", "-", "+", ".", "1.", ".5", "--1", "+-1", "1.2.3", "abc", "1,5", "1 000",
+		"1e5", "1E-2", "0x10", "Inf", "NaN",
+		"0.000000001", // Больше MoneyScale знаков после запятой
+		"1" + strings.Repeat("0", MoneyIntDigits), // Переполнение NUMERIC(38, 8)
+	}
+	for _, in := range invalid {
+		if m, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q) = %s, want error", in, m)
+		}
+	}
+}
+
+func TestMoneyStringRoundTrip(t *testing.T) {
+	for _, in := range []string{"0", "1", "-1", "0.5", "-0.00000001", "123456789012345678901.12345678"} {
+		m := MustParseMoney(in)
+		again, err := ParseMoney(m.String())
+		if err != nil || again.Cmp(m) != 0 || m.String() != in {
+			t.Errorf("round trip of %q: String() = %q, reparsed %s, %v", in, m.String(), again, err)
+		}
+	}
+
+	var zero Money
+	if zero.String() != "0" || !zero.IsZero() || zero.Add(MustParseMoney("1")).String() != "1" {
+		t.Errorf("zero Money = %q, want usable zero", zero.String())
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var m Money
+	for _, in := range []string{`"3.50"`, `3.50`} {
+		if err := m.UnmarshalJSON([]byte(in)); err != nil || m.String() != "3.5" {
+			t.Errorf("UnmarshalJSON(%s) = %s, %v", in, m, err)
+		}
+	}
+	if err := m.UnmarshalJSON([]byte(`1e3`)); err == nil {
+		t.Errorf("UnmarshalJSON(1e3) = %s, want error", m)
+	}
+	if data, err := MustParseMoney("0.1").MarshalJSON(); err != nil || string(data) != `"0.1"` {
+		t.Errorf("MarshalJSON = %s, %v", data, err)
+	}
+}
+
+func TestMoneyScanValue(t *testing.T) {
+	tests := []struct {
+		src  any
+		want string
+	}{
+		{"12.50000000", "12.5"},
+		{[]byte("-0.1"), "-0.1"},
+		{int64(42), "42"},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := m.Scan(tt.src); err != nil || m.String() != tt.want {
+			t.Errorf("Scan(%#v) = %s, %v; want %s", tt.src, m, err, tt.want)
+		}
+		v, err := m.Value()
+		if err != nil || v != tt.want {
+			t.Errorf("Value of %s = %#v, %v", m, v, err)
+		}
+	}
+
+	// Число с плавающей точкой теряет точность, поэтому не принимается
+	for _, src := range []any{float64(0.1), float32(1), nil, "1e5"} {
+		var m Money
+		if err := m.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %s, want error", src, m)
+		}
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct{ amount, percent, want string }{
+		{"100", "1.5", "1.5"},
+		{"0.00000001", "50", "0.00000001"}, // 0.000000005 округляется от нуля
+		{"0.00000001", "49", "0"},
+		{"-0.00000003", "50", "-0.00000002"},
+		{"123456789.12345678", "0", "0"},
+		{"33.33", "100", "33.33"},
+	}
+	for _, tt := range tests {
+		if got := MustParseMoney(tt.amount).Percent(MustParseMoney(tt.percent)); got.Cmp(MustParseMoney(tt.want)) != 0 {
+			t.Errorf("%s%% of %s = %s, want %s", tt.percent, tt.amount, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,50 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/google/uuid"
 )
 
 type TransferMoneyRequest struct {
 	From   uuid.UUID `json:"from"`
 	To     uuid.UUID `json:"to"`
-	Amount string    `json:"amount"`
+	Amount Money     `json:"amount"`
+
+	// IdempotencyKey берётся из заголовка Idempotency-Key, пустая строка — ключ не передан.
+	IdempotencyKey string `json:"-"`
+	// Fee — комиссия, которая списывается с отправителя сверх Amount и зачисляется на FeeWallet.
+	Fee       Money     `json:"-"`
+	FeeWallet uuid.UUID `json:"-"`
+	// Limits — лимиты исходящих переводов отправителя, которые хранилище проверяет
+	// в транзакции перевода; nil — без лимитов.
+	Limits *TransferLimits `json:"-"`
+}
+
+// Fingerprint возвращает SHA-256 отпечаток параметров перевода.
+// Используется для проверки, что повтор с тем же Idempotency-Key несёт то же тело запроса.
+func (r TransferMoneyRequest) Fingerprint() string {
+	h := sha256.New()
+	h.Write(r.From[:])
+	h.Write(r.To[:])
+	h.Write([]byte(r.Amount.String()))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type CreateWalletRequest struct {
+	OwnerRef       *string         `json:"owner_ref"`
+	Metadata       json.RawMessage `json:"metadata"`
+	OpeningBalance Money           `json:"opening_balance"`
+}
+
+type IssueAPIKeyRequest struct {
+	Name    string      `json:"name"`
+	Role    APIKeyRole  `json:"role"`
+	Wallets []uuid.UUID `json:"wallets"`
+}
+
+type UpdateWalletRequest struct {
+	Status WalletStatus `json:"status"`
+	Reason string       `json:"reason"`
 }
@@ -2,46 +2,58 @@ package model
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/google/uuid"
-	"math/big"
 	"time"
 )
 
 type TransferMoneyResponse struct {
 	HttpStatus    int
 	TransactionId uuid.UUID
+	Fee           Money
 }
 
 type TransactionInfoResponse struct {
 	TransactionId uuid.UUID `json:"transactionId"`
 	From          uuid.UUID `json:"from"`
 	To            uuid.UUID `json:"to"`
-	Amount        BigFloat  `json:"amount"`
+	Amount        Money     `json:"amount"`
+	Fee           Money     `json:"fee"`
 	TransferDate  time.Time `json:"transferDate"`
 }
 
-type WalletResponse struct {
-	Id         uuid.UUID `json:"id"`
-	Balance    BigFloat  `json:"balance"`
-	DateUpdate time.Time `json:"date_update"`
+// TransferQuoteResponse — предварительный расчёт комиссии перевода.
+// Total — сумма, которая будет списана с отправителя.
+type TransferQuoteResponse struct {
+	From   uuid.UUID `json:"from"`
+	To     uuid.UUID `json:"to"`
+	Amount Money     `json:"amount"`
+	Fee    Money     `json:"fee"`
+	Total  Money     `json:"total"`
 }
 
-type BigFloat big.Float
+type TransactionPageResponse struct {
+	Transactions []TransactionInfoResponse `json:"transactions"`
+	NextCursor   string                    `json:"nextCursor,omitempty"`
+}
 
-func (f *BigFloat) MarshalJSON() ([]byte, error) {
-	s := (*big.Float)(f).Text('f', -1)
-	return json.Marshal(s)
+// IssuedAPIKeyResponse — выпущенный ключ API. Key возвращается только при выпуске.
+type IssuedAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type WalletResponse struct {
+	Id         uuid.UUID `json:"id"`
+	Balance    Money     `json:"balance"`
+	DateUpdate time.Time `json:"date_update"`
 }
 
-func (f *BigFloat) UnmarshalJSON(data []byte) error {
-	var s string
-	if err := json.Unmarshal(data, &s); err != nil {
-		return err
-	}
-	_, ok := (*big.Float)(f).SetString(s)
-	if !ok {
-		return fmt.Errorf("invalid big.Float string: %s", s)
-	}
-	return nil
+type WalletDetailsResponse struct {
+	Id         uuid.UUID       `json:"id"`
+	Balance    Money           `json:"balance"`
+	Status     WalletStatus    `json:"status"`
+	OwnerRef   *string         `json:"owner_ref,omitempty"`
+	Metadata   json.RawMessage `json:"metadata"`
+	CreatedAt  time.Time       `json:"created_at"`
+	DateUpdate time.Time       `json:"date_update"`
 }
@@ -0,0 +1,107 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit — параметры корзины: Burst токенов, пополняемых со скоростью Rate в секунду.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result — результат попытки взять токен.
+type Result struct {
+	Allowed    bool
+	Limit      int           // Ёмкость корзины
+	Remaining  int           // Целых токенов в корзине после попытки
+	RetryAfter time.Duration // Через сколько появится токен; 0, если запрос разрешён
+	Reset      time.Duration // Через сколько корзина наполнится полностью
+}
+
+// Store хранит корзины токенов. MemoryStore подходит для одного узла;
+// при нескольких репликах нужна реализация поверх общего хранилища.
+type Store interface {
+	// Take берёт токен из корзины key, создавая её полной при первом обращении.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket — состояние корзины в памяти.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // Когда корзина наполнится, если не брать токены
+}
+
+// minSweep — число корзин, при котором начинается удаление наполнившихся.
+const minSweep = 1024
+
+// MemoryStore — потокобезопасное хранилище корзин в памяти процесса.
+// Наполнившиеся корзины неотличимы от новых и удаляются, когда число корзин
+// вырастает вдвое с прошлой очистки.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	nextSweep int
+	now       func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore создаёт пустое хранилище корзин.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), nextSweep: minSweep, now: time.Now}
+}
+
+// Take берёт токен из корзины key.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+	burst := float64(limit.Burst)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		s.sweep(now)
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	} else {
+		b.tokens = min(burst, b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+		b.updated = now
+	}
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((burst - b.tokens) / limit.Rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep удаляет наполнившиеся корзины. Вызывается под блокировкой.
+func (s *MemoryStore) sweep(now time.Time) {
+	if len(s.buckets)+1 < s.nextSweep {
+		return
+	}
+	for k, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, k)
+		}
+	}
+	s.nextSweep = max(minSweep, 2*len(s.buckets))
+}
+
+// seconds переводит секунды в time.Duration с округлением вверх до миллисекунды.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s*1000)) * time.Millisecond
+}
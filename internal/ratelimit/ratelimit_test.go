@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testStore возвращает хранилище с управляемыми часами.
+func testStore() (*MemoryStore, *time.Time) {
+	s := NewMemoryStore()
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestTokenBucket(t *testing.T) {
+	s, now := testStore()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := range 3 {
+		res, err := s.Take(t.Context(), "client", limit)
+		if err != nil || !res.Allowed || res.Remaining != 2-i || res.Limit != 3 {
+			t.Fatalf("take #%d = %+v, %v", i, res, err)
+		}
+	}
+
+	res, _ := s.Take(t.Context(), "client", limit)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond || res.Reset != 1500*time.Millisecond {
+		t.Fatalf("take from an empty bucket = %+v, want denied with retry in 500ms", res)
+	}
+	if res, _ := s.Take(t.Context(), "other", limit); !res.Allowed {
+		t.Error("buckets of different keys must be independent")
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if res, _ := s.Take(t.Context(), "client", limit); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("take after refill = %+v, want allowed", res)
+	}
+
+	// Корзина не наполняется выше ёмкости
+	*now = now.Add(time.Hour)
+	if res, _ := s.Take(t.Context(), "client", limit); res.Remaining != 2 {
+		t.Errorf("remaining after a long pause = %d, want 2", res.Remaining)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	s, now := testStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	for i := range minSweep - 1 {
+		_, _ = s.Take(t.Context(), strconv.Itoa(i), limit)
+	}
+
+	*now = now.Add(time.Minute)
+	_, _ = s.Take(t.Context(), "fresh", limit)
+	if len(s.buckets) != 1 {
+		t.Errorf("store holds %d buckets after sweep, want only the fresh one", len(s.buckets))
+	}
+}
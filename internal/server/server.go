@@ -1,9 +1,11 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"golang-server/internal/api"
 	"golang-server/internal/config"
+	"net"
 	"net/http"
 	"time"
 )
@@ -11,21 +13,42 @@ import (
 // HTTPServer обёртка над http.Server с удобными методами запуска и завершения.
 type HTTPServer struct {
 	srv *http.Server
+	// cancelRequests отменяет контексты всех запросов, если они не успели завершиться за время остановки
+	cancelRequests context.CancelFunc
 }
 
+// writeTimeoutMargin — запас WriteTimeout сверх срока обработки запроса,
+// чтобы ответ 504 по истечении срока ещё успел уйти клиенту.
+const writeTimeoutMargin = time.Second
+
 // NewHTTPServer создаёт новый HTTPServer с конфигурацией и роутером.
+// Timeout из конфигурации ограничивает и чтение запроса, и срок контекста обработчика:
+// по его истечении запросы к базе прерываются, а клиент получает 504.
 func NewHTTPServer(cfg config.ServerConfig, router *api.Router) *HTTPServer {
+	timeout := time.Duration(cfg.Timeout)
+	baseCtx, cancel := context.WithCancel(context.Background())
 	return &HTTPServer{
 		srv: &http.Server{
 			Addr:         cfg.Host + ":" + cfg.Port,
-			Handler:      router.Handler(),
-			ReadTimeout:  time.Duration(cfg.Timeout) * time.Second,
-			WriteTimeout: time.Duration(cfg.Timeout) * time.Second,
-			IdleTimeout:  time.Duration(cfg.IdleTimeout) * time.Second,
+			Handler:      withRequestTimeout(router.Handler(), timeout),
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout + writeTimeoutMargin,
+			IdleTimeout:  time.Duration(cfg.IdleTimeout),
+			BaseContext:  func(net.Listener) context.Context { return baseCtx },
 		},
+		cancelRequests: cancel,
 	}
 }
 
+// withRequestTimeout устанавливает срок контекста каждого запроса.
+func withRequestTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // ListenAndServe запускает HTTP сервер и возвращает ошибку,
 // если сервер не был корректно остановлен.
 func (s *HTTPServer) ListenAndServe() error {
@@ -34,3 +57,21 @@ func (s *HTTPServer) ListenAndServe() error {
 	}
 	return nil
 }
+
+// Shutdown останавливает сервер: закрывает слушающий сокет и ждёт завершения
+// обрабатываемых запросов, пока не истечёт ctx.
+// Если запросы не успели завершиться, их контексты отменяются (запросы к базе прерываются),
+// а соединения закрываются принудительно.
+// Параметры:
+//   - ctx: контекст с предельным временем ожидания.
+//
+// Возвращает:
+//   - error: ошибку ctx, если запросы не завершились вовремя.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	s.cancelRequests()
+	if err != nil {
+		_ = s.srv.Close()
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage"
+)
+
+// APIKeyService выпускает, отзывает и проверяет ключи API.
+type APIKeyService struct {
+	keyRepository storage.APIKeyStorage
+}
+
+// NewAPIKeyService создаёт новый APIKeyService поверх хранилища ключей.
+func NewAPIKeyService(keys storage.APIKeyStorage) APIKeyService {
+	return APIKeyService{keyRepository: keys}
+}
+
+// Issue выпускает новый ключ API. Сам ключ возвращается только здесь:
+// в хранилище попадает лишь его хеш.
+func (ks *APIKeyService) Issue(ctx context.Context, req model.IssueAPIKeyRequest) (*model.IssuedAPIKeyResponse, error) {
+	key, hash, err := auth.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := model.APIKey{
+		Id:      uuid.New(),
+		Name:    req.Name,
+		Hash:    hash,
+		Role:    req.Role,
+		Wallets: req.Wallets,
+	}
+	if apiKey.Wallets == nil {
+		apiKey.Wallets = []uuid.UUID{}
+	}
+	if err := ks.keyRepository.CreateAPIKey(ctx, &apiKey); err != nil {
+		return nil, err
+	}
+
+	return &model.IssuedAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// Revoke отзывает ключ API. Запросы с отозванным ключом отклоняются сразу.
+func (ks *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	return ks.keyRepository.RevokeAPIKey(ctx, id)
+}
+
+// List возвращает все ключи API, от новых к старым.
+func (ks *APIKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	return ks.keyRepository.ListAPIKeys(ctx)
+}
+
+// Authenticate находит ключ по его хешу и возвращает вызывающего.
+// Неизвестный и отозванный ключи дают domain.ErrUnauthorized без уточнения причины.
+func (ks *APIKeyService) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	apiKey, err := ks.keyRepository.GetAPIKeyByHash(ctx, auth.HashKey(key))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, domain.ErrUnauthorized
+	}
+
+	scopes := auth.ClientScopes
+	if apiKey.Role == model.APIKeyAdmin {
+		scopes = auth.AdminScopes
+	}
+	return &auth.Principal{
+		Subject: "api_key:" + apiKey.Id.String(),
+		KeyId:   apiKey.Id,
+		Name:    apiKey.Name,
+		Scopes:  scopes,
+		Wallets: apiKey.Wallets,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"golang-server/internal/domain"
+	"golang-server/internal/metrics"
+	"golang-server/internal/model"
+)
+
+var (
+	transfersTotal = metrics.Default.NewCounterVec(
+		"wallet_transfers_total", "Number of completed transfers.")
+	transferVolume = metrics.Default.NewCounterVec(
+		"wallet_transfer_volume_total", "Total amount of completed transfers.")
+	transferFees = metrics.Default.NewCounterVec(
+		"wallet_transfer_fees_total", "Total fees charged on completed transfers.")
+	transferFailures = metrics.Default.NewCounterVec(
+		"wallet_transfer_failures_total", "Number of failed transfers by error class.", "class")
+)
+
+// recordTransfer учитывает результат перевода в метриках.
+func recordTransfer(amount, fee model.Money, err error) {
+	if err != nil {
+		transferFailures.Inc(failureClass(err))
+		return
+	}
+	transfersTotal.Inc()
+	transferVolume.Add(amount.Float64())
+	transferFees.Add(fee.Float64())
+}
+
+// failureClass возвращает класс ошибки перевода для метки метрики:
+// код доменной ошибки, canceled, timeout или internal.
+func failureClass(err error) string {
+	var domainErr *domain.Error
+	switch {
+	case errors.As(err, &domainErr):
+		return domainErr.Code
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "internal"
+}
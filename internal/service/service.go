@@ -1,80 +1,260 @@
 package service
 
 import (
+	"context"
 	"github.com/google/uuid"
+	"golang-server/internal/auth"
+	"golang-server/internal/domain"
+	"golang-server/internal/fees"
+	"golang-server/internal/limits"
 	"golang-server/internal/model"
 	"golang-server/internal/storage"
-	"golang-server/internal/storage/postgres"
+	"golang-server/internal/tracing"
+	"log/slog"
 	"net/http"
 )
 
 // TransactionService обрабатывает операции с транзакциями.
+// Методы сервисов принимают контекст HTTP-запроса и передают его в хранилище,
+// поэтому отключение клиента или истечение срока запроса прерывает запросы к базе.
+// Каждый вызов выполняется в отдельном span'е трассировки.
 type TransactionService struct {
-	walletRepository      storage.WalletRepository
-	transactionRepository storage.TransactionRepository
+	walletRepository      storage.WalletStorage
+	transactionRepository storage.TransactionStorage
+	limits                *limits.Engine
+	fees                  *fees.Schedule
 }
 
 // WalletService обрабатывает операции с кошельками.
 type WalletService struct {
-	walletRepository storage.WalletRepository
+	walletRepository      storage.WalletStorage
+	transactionRepository storage.TransactionStorage
 }
 
-// NewTransactionService создаёт новый TransactionService с подключением к базе данных.
-func NewTransactionService(db *postgres.PgDB) TransactionService {
+// NewTransactionService создаёт новый TransactionService поверх хранилищ кошельков и переводов.
+// Переводы проверяются по лимитам engine и облагаются комиссией по schedule;
+// nil engine лимитов не задаёт, nil schedule комиссий не берёт.
+func NewTransactionService(wallets storage.WalletStorage, transactions storage.TransactionStorage, engine *limits.Engine, schedule *fees.Schedule) TransactionService {
 	return TransactionService{
-		walletRepository:      *storage.NewWalletRepository(db),
-		transactionRepository: *storage.NewTransactionRepository(db),
+		walletRepository:      wallets,
+		transactionRepository: transactions,
+		limits:                engine,
+		fees:                  schedule,
 	}
 }
 
-// NewWalletService создаёт новый WalletService с подключением к базе данных.
-func NewWalletService(db *postgres.PgDB) WalletService {
-	return WalletService{walletRepository: *storage.NewWalletRepository(db)}
+// NewWalletService создаёт новый WalletService поверх хранилищ кошельков и переводов.
+func NewWalletService(wallets storage.WalletStorage, transactions storage.TransactionStorage) WalletService {
+	return WalletService{
+		walletRepository:      wallets,
+		transactionRepository: transactions,
+	}
 }
 
 // SendMoney выполняет перевод средств между кошельками.
-// Проверяет наличие баланса у отправителя и возвращает HTTP-статус и ID транзакции.
-func (ts *TransactionService) SendMoney(data model.TransferMoneyRequest) (model.TransferMoneyResponse, error) {
-	id, err := ts.transactionRepository.SendMoney(data)
+// Проверяет наличие баланса у отправителя и возвращает HTTP-статус, ID транзакции и удержанную комиссию.
+// Перевод на тот же кошелёк отклоняется с ошибкой domain.ErrSameWallet.
+// Сумма сразу проверяется по лимиту на один перевод, остальные лимиты отправителя
+// хранилище проверяет в транзакции перевода.
+// Результат учитывается в метриках переводов; повтор по ключу идемпотентности повторно не учитывается.
+func (ts *TransactionService) SendMoney(ctx context.Context, data model.TransferMoneyRequest) (_ model.TransferMoneyResponse, err error) {
+	var replayed bool
+	defer func() {
+		if !replayed {
+			recordTransfer(data.Amount, data.Fee, err)
+		}
+	}()
+	ctx, span := tracing.Start(ctx, "TransactionService.SendMoney", tracing.KindInternal,
+		slog.String("wallet.from", data.From.String()),
+		slog.String("wallet.to", data.To.String()),
+		slog.Bool("transfer.idempotent", data.IdempotencyKey != ""),
+	)
+	defer span.Finish(&err)
+
+	if data.From == data.To {
+		return model.TransferMoneyResponse{HttpStatus: http.StatusBadRequest}, domain.ErrSameWallet
+	}
+
+	data.Limits = ts.limits.For(data.From)
+	if err := limits.CheckAmount(data.Limits, data.Amount); err != nil {
+		return model.TransferMoneyResponse{HttpStatus: http.StatusUnprocessableEntity}, err
+	}
+	data.Fee = ts.fees.Quote(data.From, data.Amount)
+	data.FeeWallet = ts.fees.Wallet()
+
+	transaction, err := ts.transactionRepository.SendMoney(ctx, data)
 	if err != nil {
 		return model.TransferMoneyResponse{HttpStatus: http.StatusInternalServerError}, err
 	}
 
-	return model.TransferMoneyResponse{HttpStatus: http.StatusOK, TransactionId: *id}, nil
+	replayed = transaction.Replayed
+	span.SetAttributes(
+		slog.String("transaction.id", transaction.Id.String()),
+		slog.String("transaction.fee", transaction.Fee.String()),
+		slog.Bool("transaction.replayed", transaction.Replayed),
+	)
+	return model.TransferMoneyResponse{HttpStatus: http.StatusOK, TransactionId: transaction.Id, Fee: transaction.Fee}, nil
+}
+
+// QuoteTransfer рассчитывает комиссию перевода без перевода средств.
+// Перевод на тот же кошелёк отклоняется с ошибкой domain.ErrSameWallet.
+func (ts *TransactionService) QuoteTransfer(ctx context.Context, data model.TransferMoneyRequest) (model.TransferQuoteResponse, error) {
+	if data.From == data.To {
+		return model.TransferQuoteResponse{}, domain.ErrSameWallet
+	}
+
+	fee := ts.fees.Quote(data.From, data.Amount)
+	return model.TransferQuoteResponse{
+		From:   data.From,
+		To:     data.To,
+		Amount: data.Amount,
+		Fee:    fee,
+		Total:  data.Amount.Add(fee),
+	}, nil
 }
 
 // GetLastTransactions возвращает последние numberOfTx транзакций.
-func (ts *TransactionService) GetLastTransactions(numberOfTx int) ([]model.TransactionInfoResponse, error) {
-	tx, err := ts.transactionRepository.GetLastTransactions(numberOfTx)
+func (ts *TransactionService) GetLastTransactions(ctx context.Context, numberOfTx int) (_ []model.TransactionInfoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetLastTransactions", tracing.KindInternal,
+		slog.Int("transactions.count", numberOfTx),
+	)
+	defer span.Finish(&err)
+
+	tx, err := ts.transactionRepository.GetLastTransactions(ctx, numberOfTx)
 	if err != nil {
 		return nil, err
 	}
 
+	return toTransactionInfos(tx), nil
+}
+
+// toTransactionInfos преобразует транзакции в ответ API.
+func toTransactionInfos(tx []model.Transaction) []model.TransactionInfoResponse {
 	response := make([]model.TransactionInfoResponse, 0, len(tx))
 	for _, t := range tx {
 		response = append(response, model.TransactionInfoResponse{
 			TransactionId: t.Id,
 			From:          t.From,
 			To:            t.To,
-			Amount:        model.BigFloat(t.Amount),
+			Amount:        t.Amount,
+			Fee:           t.Fee,
 			TransferDate:  t.TransferDate,
 		})
 	}
-
-	return response, nil
+	return response
 }
 
 // GetWalletInfo возвращает информацию о кошельке по его UUID.
-func (ws *WalletService) GetWalletInfo(id uuid.UUID) (*model.WalletResponse, error) {
-	r, err := ws.walletRepository.GetWallet(id)
+func (ws *WalletService) GetWalletInfo(ctx context.Context, id uuid.UUID) (*model.WalletResponse, error) {
+	r, err := ws.walletRepository.GetWallet(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	response := model.WalletResponse{
 		Id:         r.Id,
-		Balance:    model.BigFloat(r.Balance),
+		Balance:    r.Balance,
 		DateUpdate: r.DateUpdate,
 	}
 	return &response, nil
 }
+
+// CreateWallet создаёт новый активный кошелёк с начальным балансом.
+func (ws *WalletService) CreateWallet(ctx context.Context, req model.CreateWalletRequest) (*model.WalletDetailsResponse, error) {
+	wallet := model.Wallet{
+		Id:       uuid.New(),
+		Balance:  req.OpeningBalance,
+		Status:   model.WalletActive,
+		OwnerRef: req.OwnerRef,
+		Metadata: req.Metadata,
+	}
+	if err := ws.walletRepository.CreateWallet(ctx, &wallet); err != nil {
+		return nil, err
+	}
+
+	return toWalletDetails(&wallet), nil
+}
+
+// GetWalletDetails возвращает полную информацию о кошельке по его UUID.
+func (ws *WalletService) GetWalletDetails(ctx context.Context, id uuid.UUID) (*model.WalletDetailsResponse, error) {
+	wallet, err := ws.walletRepository.GetWallet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toWalletDetails(wallet), nil
+}
+
+// defaultStatusActor — инициатор смены статуса, если запрос не аутентифицирован.
+const defaultStatusActor = "api"
+
+// UpdateWalletStatus меняет статус кошелька: заморозка, разморозка или закрытие.
+// Изменение вместе с причиной и инициатором записывается в историю статусов.
+// Инициатором всегда становится субъект аутентифицированного запроса: клиент не может его подменить.
+func (ws *WalletService) UpdateWalletStatus(ctx context.Context, id uuid.UUID, req model.UpdateWalletRequest) (*model.WalletDetailsResponse, error) {
+	change := model.WalletStatusChange{
+		NewStatus: req.Status,
+		Reason:    req.Reason,
+		Actor:     auth.Subject(ctx),
+	}
+	if change.Actor == "" {
+		change.Actor = defaultStatusActor
+	}
+
+	wallet, err := ws.walletRepository.UpdateWalletStatus(ctx, id, change)
+	if err != nil {
+		return nil, err
+	}
+
+	return toWalletDetails(wallet), nil
+}
+
+// GetWalletStatusHistory возвращает историю смены статусов кошелька.
+func (ws *WalletService) GetWalletStatusHistory(ctx context.Context, id uuid.UUID) ([]model.WalletStatusChange, error) {
+	// Проверка существования, чтобы отличить неизвестный кошелёк от пустой истории
+	if _, err := ws.walletRepository.GetWallet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return ws.walletRepository.GetWalletStatusHistory(ctx, id)
+}
+
+// GetWalletTransactions возвращает страницу истории переводов кошелька.
+// Курсор следующей страницы заполняется, только если за текущей страницей есть записи.
+func (ws *WalletService) GetWalletTransactions(ctx context.Context, filter model.TransactionFilter) (*model.TransactionPageResponse, error) {
+	// Проверка существования, чтобы отличить неизвестный кошелёк от пустой истории
+	if _, err := ws.walletRepository.GetWallet(ctx, filter.WalletId); err != nil {
+		return nil, err
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+	tx, err := ws.transactionRepository.GetWalletTransactions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := model.TransactionPageResponse{}
+	if len(tx) > limit {
+		tx = tx[:limit]
+		last := tx[len(tx)-1]
+		page.NextCursor = model.TransactionCursor{TransferDate: last.TransferDate, Id: last.Id}.Encode()
+	}
+	page.Transactions = toTransactionInfos(tx)
+
+	return &page, nil
+}
+
+// toWalletDetails преобразует модель кошелька в ответ API.
+func toWalletDetails(w *model.Wallet) *model.WalletDetailsResponse {
+	return &model.WalletDetailsResponse{
+		Id:         w.Id,
+		Balance:    w.Balance,
+		Status:     w.Status,
+		OwnerRef:   w.OwnerRef,
+		Metadata:   w.Metadata,
+		CreatedAt:  w.CreatedAt,
+		DateUpdate: w.DateUpdate,
+	}
+}
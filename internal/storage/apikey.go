@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage/postgres"
+	"time"
+)
+
+// APIKeyRepository управляет ключами API.
+type APIKeyRepository struct {
+	db           *postgres.PgDB
+	runner       *TxRunner
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// NewAPIKeyRepository создаёт новый репозиторий ключей API.
+func NewAPIKeyRepository(db *postgres.PgDB) *APIKeyRepository {
+	cfg := db.Config()
+	return &APIKeyRepository{
+		db:           db,
+		runner:       NewTxRunner(db, cfg),
+		readTimeout:  time.Duration(cfg.ReadTimeout),
+		writeTimeout: time.Duration(cfg.WriteTimeout),
+	}
+}
+
+// apiKeyColumns — список столбцов ключа в порядке, ожидаемом scanAPIKey.
+const apiKeyColumns = "id, name, key_hash, role, created_at, revoked_at"
+
+// scanAPIKey считывает ключ без списка кошельков.
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*model.APIKey, error) {
+	var key model.APIKey
+	var revokedAt sql.NullTime
+	if err := row.Scan(&key.Id, &key.Name, &key.Hash, &key.Role, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	key.Wallets = []uuid.UUID{}
+	return &key, nil
+}
+
+// CreateAPIKey сохраняет ключ и его привязку к кошелькам в одной транзакции.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - key: ключ с заполненными Id, Name, Hash, Role и Wallets; CreatedAt заполняется репозиторием.
+//
+// Возвращает:
+//   - error: domain.ErrWalletNotFound, если одного из кошельков нет, или ошибку при выполнении транзакции.
+func (kr *APIKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (err error) {
+	ctx, done := OperationContext(ctx, kr.writeTimeout)
+	defer done(&err)
+
+	return kr.runner.Run(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context, tx *sql.Tx) error {
+		// Проверка существования до вставки, чтобы неизвестный кошелёк давал доменную ошибку, а не нарушение внешнего ключа
+		for _, walletId := range key.Wallets {
+			var exists bool
+			if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM wallets WHERE id = $1)", walletId).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+			}
+		}
+
+		insertQuery := "INSERT INTO api_keys (id, name, key_hash, role, created_at) VALUES ($1, $2, $3, $4, NOW()) RETURNING created_at"
+		if err := tx.QueryRowContext(ctx, insertQuery, key.Id, key.Name, key.Hash, key.Role).Scan(&key.CreatedAt); err != nil {
+			return err
+		}
+
+		for _, walletId := range key.Wallets {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO api_key_wallets (key_id, wallet_id) VALUES ($1, $2)", key.Id, walletId); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAPIKeyByHash возвращает ключ по SHA-256 хешу.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - hash: хеш ключа в шестнадцатеричном виде.
+//
+// Возвращает:
+//   - *model.APIKey: ключ со списком кошельков, в том числе отозванный.
+//   - error: domain.ErrAPIKeyNotFound или ошибку при выполнении запроса.
+func (kr *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (_ *model.APIKey, err error) {
+	ctx, done := OperationContext(ctx, kr.readTimeout)
+	defer done(&err)
+
+	key, err := scanAPIKey(kr.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Wallets, err = kr.keyWallets(ctx, key.Id); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys возвращает все ключи, от новых к старым.
+// Возвращает:
+//   - []model.APIKey: ключи со списками кошельков.
+//   - error: ошибку при выполнении запроса.
+func (kr *APIKeyRepository) ListAPIKeys(ctx context.Context) (_ []model.APIKey, err error) {
+	ctx, done := OperationContext(ctx, kr.readTimeout)
+	defer done(&err)
+
+	rows, err := kr.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		if keys[i].Wallets, err = kr.keyWallets(ctx, keys[i].Id); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время первого.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - id: идентификатор ключа.
+//
+// Возвращает:
+//   - *model.APIKey: ключ после отзыва.
+//   - error: domain.ErrAPIKeyNotFound или ошибку при выполнении запроса.
+func (kr *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (_ *model.APIKey, err error) {
+	ctx, done := OperationContext(ctx, kr.writeTimeout)
+	defer done(&err)
+
+	query := "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 RETURNING " + apiKeyColumns
+	key, err := scanAPIKey(kr.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Wallets, err = kr.keyWallets(ctx, key.Id); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// keyWallets возвращает кошельки, привязанные к ключу.
+func (kr *APIKeyRepository) keyWallets(ctx context.Context, keyId uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := kr.db.QueryContext(ctx, "SELECT wallet_id FROM api_key_wallets WHERE key_id = $1 ORDER BY wallet_id", keyId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	wallets := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var walletId uuid.UUID
+		if err := rows.Scan(&walletId); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, walletId)
+	}
+	return wallets, rows.Err()
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OperationContext ограничивает ctx таймаутом операции с базой данных.
+// Нулевой или отрицательный timeout оставляет только срок и отмену исходного ctx.
+// Возвращаемую функцию завершения нужно вызвать через defer с адресом возвращаемой ошибки:
+// она освобождает контекст и, если операция прервана отменой или истечением срока,
+// оборачивает ошибку драйвера в context.Canceled или context.DeadlineExceeded.
+// Драйверы сообщают об отмене по-своему (lib/pq, например, SQLSTATE 57014),
+// и без обёртки вызывающий код не отличил бы отмену от сбоя базы.
+func OperationContext(ctx context.Context, timeout time.Duration) (context.Context, func(errp *error)) {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func(errp *error) {
+		if err := *errp; err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+				*errp = fmt.Errorf("%w: %w", ctxErr, err)
+			}
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOperationContext(t *testing.T) {
+	driverErr := errors.New("pq: canceling statement due to user request")
+
+	ctx, done := OperationContext(t.Context(), time.Nanosecond)
+	<-ctx.Done()
+	err := driverErr
+	done(&err)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, driverErr) {
+		t.Errorf("error after deadline = %v, want both %v and the driver error", err, context.DeadlineExceeded)
+	}
+
+	_, done = OperationContext(t.Context(), time.Minute)
+	err = driverErr
+	done(&err)
+	if err != driverErr {
+		t.Errorf("error without cancellation = %v, want it unchanged", err)
+	}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/model"
+)
+
+// TransferEntries возвращает проводки перевода: дебет отправителя и кредит получателя,
+// а при ненулевой комиссии — ещё дебет отправителя на комиссию и кредит кошелька для комиссий.
+// Время проводок заполняет хранилище.
+func TransferEntries(transactionId uuid.UUID, data model.TransferMoneyRequest) []model.LedgerEntry {
+	entries := []model.LedgerEntry{
+		{Id: uuid.New(), TransactionId: &transactionId, WalletId: data.From, Type: model.LedgerDebit, Amount: data.Amount.Neg()},
+		{Id: uuid.New(), TransactionId: &transactionId, WalletId: data.To, Type: model.LedgerCredit, Amount: data.Amount},
+	}
+	if data.Fee.Sign() > 0 {
+		entries = append(entries,
+			model.LedgerEntry{Id: uuid.New(), TransactionId: &transactionId, WalletId: data.From, Type: model.LedgerDebit, Amount: data.Fee.Neg()},
+			model.LedgerEntry{Id: uuid.New(), TransactionId: &transactionId, WalletId: data.FeeWallet, Type: model.LedgerCredit, Amount: data.Fee},
+		)
+	}
+	return entries
+}
+
+// postTransfer записывает проводки перевода (см. TransferEntries).
+// После вставки проверяет по базе, что сумма проводок транзакции равна нулю;
+// нарушение инварианта возвращается ошибкой и откатывает перевод.
+// Параметры:
+//   - ctx: контекст транзакции.
+//   - tx: открытая транзакция перевода.
+//   - transactionId: ID записи в таблице transactions.
+//   - data: перевод с кошельками, суммой и комиссией.
+//
+// Возвращает:
+//   - error: ошибку при вставке проводок или нарушении инварианта.
+func postTransfer(ctx context.Context, tx Querier, transactionId uuid.UUID, data model.TransferMoneyRequest) error {
+	entries := TransferEntries(transactionId, data)
+
+	insertQuery := "INSERT INTO ledger_entries (id, transaction_id, wallet_id, entry_type, amount, created_at) VALUES ($1, $2, $3, $4, $5, NOW())"
+	for _, e := range entries {
+		if _, err := tx.ExecContext(ctx, insertQuery, e.Id, e.TransactionId, e.WalletId, e.Type, e.Amount); err != nil {
+			return err
+		}
+	}
+
+	var sum model.Money
+	sumQuery := "SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE transaction_id = $1"
+	if err := tx.QueryRowContext(ctx, sumQuery, transactionId).Scan(&sum); err != nil {
+		return err
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("ledger invariant violated: postings of transaction %s sum to %s", transactionId, sum)
+	}
+	return nil
+}
+
+// GetLedgerBalance пересчитывает баланс кошелька по журналу проводок.
+// Результат должен совпадать с wallets.balance; расхождение означает повреждение данных.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - walletId: идентификатор кошелька.
+//
+// Возвращает:
+//   - model.Money: сумма всех проводок кошелька.
+//   - error: ошибку при выполнении запроса.
+func (wr *WalletRepository) GetLedgerBalance(ctx context.Context, walletId uuid.UUID) (_ model.Money, err error) {
+	ctx, done := OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	var balance model.Money
+	query := "SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE wallet_id = $1"
+	if err := wr.db.QueryRowContext(ctx, query, walletId).Scan(&balance); err != nil {
+		return model.Money{}, err
+	}
+	return balance, nil
+}
@@ -0,0 +1,445 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/domain"
+	"golang-server/internal/limits"
+	"golang-server/internal/model"
+	"golang-server/internal/storage"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idempotencyRecord — сохранённый ключ идемпотентности перевода.
+type idempotencyRecord struct {
+	requestHash string
+	transaction model.Transaction
+}
+
+// Store — потокобезопасное хранилище кошельков и переводов в памяти.
+// Реализует storage.WalletStorage и storage.TransactionStorage с той же семантикой,
+// что и PostgreSQL-репозитории: переводы атомарны, история упорядочена по (transfer_date, id).
+// Данные не переживают перезапуск процесса; предназначено для тестов и режима разработки.
+// Операции не блокируются на вводе-выводе, поэтому контекст проверяется только перед изменением данных.
+type Store struct {
+	mu            sync.RWMutex
+	wallets       map[uuid.UUID]*model.Wallet
+	transactions  []model.Transaction
+	ledger        []model.LedgerEntry
+	statusHistory map[uuid.UUID][]model.WalletStatusChange
+	idempotency   map[string]idempotencyRecord
+	lastTransfer  time.Time
+	apiKeys       []*model.APIKey // В порядке создания
+}
+
+var (
+	_ storage.WalletStorage      = (*Store)(nil)
+	_ storage.TransactionStorage = (*Store)(nil)
+	_ storage.APIKeyStorage      = (*Store)(nil)
+)
+
+// NewStore создаёт пустое хранилище.
+func NewStore() *Store {
+	return &Store{
+		wallets:       make(map[uuid.UUID]*model.Wallet),
+		statusHistory: make(map[uuid.UUID][]model.WalletStatusChange),
+		idempotency:   make(map[string]idempotencyRecord),
+	}
+}
+
+// now возвращает текущее время с точностью до микросекунд, как в PostgreSQL.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// copyWallet возвращает копию кошелька, чтобы вызывающий код не менял состояние хранилища.
+func copyWallet(w *model.Wallet) *model.Wallet {
+	c := *w
+	c.Metadata = slices.Clone(w.Metadata)
+	if w.OwnerRef != nil {
+		ref := *w.OwnerRef
+		c.OwnerRef = &ref
+	}
+	return &c
+}
+
+// copyAPIKey возвращает копию ключа, чтобы вызывающий код не менял состояние хранилища.
+func copyAPIKey(k *model.APIKey) model.APIKey {
+	c := *k
+	c.Wallets = slices.Clone(k.Wallets)
+	if k.RevokedAt != nil {
+		revokedAt := *k.RevokedAt
+		c.RevokedAt = &revokedAt
+	}
+	return c
+}
+
+// wallet возвращает кошелёк по ID или domain.ErrWalletNotFound. Вызывается под блокировкой.
+func (s *Store) wallet(walletId uuid.UUID) (*model.Wallet, error) {
+	w, ok := s.wallets[walletId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+	}
+	return w, nil
+}
+
+// CountWallets возвращает количество кошельков.
+func (s *Store) CountWallets(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.wallets), nil
+}
+
+// GetBalance возвращает текущий баланс кошелька.
+func (s *Store) GetBalance(ctx context.Context, walletId uuid.UUID) (model.Money, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, err := s.wallet(walletId)
+	if err != nil {
+		return model.Money{}, err
+	}
+	return w.Balance, nil
+}
+
+// GetWallet возвращает копию кошелька.
+func (s *Store) GetWallet(ctx context.Context, walletId uuid.UUID) (*model.Wallet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, err := s.wallet(walletId)
+	if err != nil {
+		return nil, err
+	}
+	return copyWallet(w), nil
+}
+
+// CreateWallet добавляет кошелёк и проводку его начального баланса.
+func (s *Store) CreateWallet(ctx context.Context, wallet *model.Wallet) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.wallets[wallet.Id]; ok {
+		return fmt.Errorf("wallet %s already exists", wallet.Id)
+	}
+	if len(wallet.Metadata) == 0 || string(wallet.Metadata) == "null" {
+		wallet.Metadata = json.RawMessage("{}")
+	}
+
+	ts := now()
+	wallet.CreatedAt = ts
+	wallet.DateUpdate = ts
+	s.wallets[wallet.Id] = copyWallet(wallet)
+	s.ledger = append(s.ledger, model.LedgerEntry{
+		Id:        uuid.New(),
+		WalletId:  wallet.Id,
+		Type:      model.LedgerOpening,
+		Amount:    wallet.Balance,
+		CreatedAt: ts,
+	})
+	return nil
+}
+
+// UpdateWalletStatus меняет статус кошелька с теми же проверками, что и WalletRepository.
+func (s *Store) UpdateWalletStatus(ctx context.Context, walletId uuid.UUID, change model.WalletStatusChange) (*model.Wallet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.wallet(walletId)
+	if err != nil {
+		return nil, err
+	}
+	if !w.Status.CanTransitionTo(change.NewStatus) {
+		return nil, fmt.Errorf("%w: %s -> %s", domain.ErrInvalidStatusTransition, w.Status, change.NewStatus)
+	}
+	if change.NewStatus == model.WalletClosed && !w.Balance.IsZero() {
+		return nil, domain.ErrWalletNotEmpty
+	}
+
+	ts := now()
+	change.Id = uuid.New()
+	change.WalletId = walletId
+	change.OldStatus = w.Status
+	change.ChangedAt = ts
+	s.statusHistory[walletId] = append(s.statusHistory[walletId], change)
+
+	w.Status = change.NewStatus
+	w.DateUpdate = ts
+	return copyWallet(w), nil
+}
+
+// GetWalletStatusHistory возвращает историю смены статусов, от новых к старым.
+func (s *Store) GetWalletStatusHistory(ctx context.Context, walletId uuid.UUID) ([]model.WalletStatusChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := slices.Clone(s.statusHistory[walletId])
+	slices.Reverse(history)
+	if history == nil {
+		history = make([]model.WalletStatusChange, 0)
+	}
+	return history, nil
+}
+
+// GetLedgerBalance пересчитывает баланс кошелька по журналу проводок.
+func (s *Store) GetLedgerBalance(ctx context.Context, walletId uuid.UUID) (model.Money, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var balance model.Money
+	for _, e := range s.ledger {
+		if e.WalletId == walletId {
+			balance = balance.Add(e.Amount)
+		}
+	}
+	return balance, nil
+}
+
+// SendMoney атомарно переводит средства под эксклюзивной блокировкой хранилища.
+// Порядок проверок и возвращаемые ошибки совпадают с TransactionRepository.SendMoney.
+func (s *Store) SendMoney(ctx context.Context, data model.TransferMoneyRequest) (*model.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if data.IdempotencyKey != "" {
+		if rec, ok := s.idempotency[data.IdempotencyKey]; ok {
+			if rec.requestHash != data.Fingerprint() {
+				return nil, domain.ErrIdempotencyConflict
+			}
+			t := rec.transaction
+			t.Replayed = true
+			return &t, nil
+		}
+	}
+
+	// Кошельки выбираются в том же порядке ID, в котором их блокирует Postgres
+	ids := storage.TransferWalletIds(data)
+	wallets := make(map[uuid.UUID]*model.Wallet, len(ids))
+	for _, id := range ids {
+		if w, ok := s.wallets[id]; ok {
+			wallets[id] = w
+		}
+	}
+	if err := storage.CheckTransferWallets(data, wallets); err != nil {
+		return nil, err
+	}
+	from, to := wallets[data.From], wallets[data.To]
+	var usage model.OutgoingUsage
+	if limits.NeedsUsage(data.Limits) {
+		usage = s.outgoingUsage(data.From)
+	}
+	if err := limits.Check(data.Limits, data.Amount, usage); err != nil {
+		return nil, err
+	}
+	if from.Balance.Cmp(data.Amount.Add(data.Fee)) < 0 {
+		return nil, domain.ErrInsufficientFunds
+	}
+
+	// Время перевода строго возрастает, чтобы порядок истории совпадал с порядком переводов
+	ts := now()
+	if !ts.After(s.lastTransfer) {
+		ts = s.lastTransfer.Add(time.Microsecond)
+	}
+	s.lastTransfer = ts
+
+	transaction := model.Transaction{
+		Id:           uuid.New(),
+		From:         data.From,
+		To:           data.To,
+		Amount:       data.Amount,
+		Fee:          data.Fee,
+		TransferDate: ts,
+	}
+	from.Balance = from.Balance.Sub(data.Amount.Add(data.Fee))
+	from.DateUpdate = ts
+	to.Balance = to.Balance.Add(data.Amount)
+	to.DateUpdate = ts
+	if data.Fee.Sign() > 0 {
+		feeWallet := wallets[data.FeeWallet]
+		feeWallet.Balance = feeWallet.Balance.Add(data.Fee)
+		feeWallet.DateUpdate = ts
+	}
+
+	s.transactions = append(s.transactions, transaction)
+	for _, e := range storage.TransferEntries(transaction.Id, data) {
+		e.CreatedAt = ts
+		s.ledger = append(s.ledger, e)
+	}
+	if data.IdempotencyKey != "" {
+		s.idempotency[data.IdempotencyKey] = idempotencyRecord{requestHash: data.Fingerprint(), transaction: transaction}
+	}
+
+	return &transaction, nil
+}
+
+// outgoingUsage считает исходящие переводы кошелька в периодах лимитов.
+func (s *Store) outgoingUsage(walletId uuid.UUID) model.OutgoingUsage {
+	w := limits.WindowsAt(time.Now())
+	var usage model.OutgoingUsage
+	for _, t := range s.transactions {
+		if t.From == walletId {
+			w.Add(&usage, t.Amount, t.TransferDate)
+		}
+	}
+	return usage
+}
+
+// GetLastTransactions возвращает последние N транзакций, от новых к старым.
+func (s *Store) GetLastTransactions(ctx context.Context, numberOfTx int) ([]model.Transaction, error) {
+	return s.GetWalletTransactions(ctx, model.TransactionFilter{Limit: numberOfTx})
+}
+
+// GetWalletTransactions возвращает переводы по фильтру, упорядоченные по (transfer_date, id) по убыванию.
+// Нулевой WalletId в фильтре означает выборку по всем кошелькам.
+func (s *Store) GetWalletTransactions(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]model.Transaction, 0, filter.Limit)
+	// Переводы хранятся в порядке возрастания времени, поэтому обходим с конца
+	for i := len(s.transactions) - 1; i >= 0 && len(result) < filter.Limit; i-- {
+		if t := s.transactions[i]; matches(t, filter) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// matches сообщает, удовлетворяет ли перевод фильтру.
+func matches(t model.Transaction, f model.TransactionFilter) bool {
+	if f.WalletId != uuid.Nil {
+		switch f.Direction {
+		case model.DirectionIncoming:
+			if t.To != f.WalletId {
+				return false
+			}
+		case model.DirectionOutgoing:
+			if t.From != f.WalletId {
+				return false
+			}
+		default:
+			if t.From != f.WalletId && t.To != f.WalletId {
+				return false
+			}
+		}
+	}
+
+	if f.FromDate != nil && t.TransferDate.Before(*f.FromDate) {
+		return false
+	}
+	if f.ToDate != nil && !t.TransferDate.Before(*f.ToDate) {
+		return false
+	}
+	if f.MinAmount != nil && t.Amount.Cmp(*f.MinAmount) < 0 {
+		return false
+	}
+	if f.MaxAmount != nil && t.Amount.Cmp(*f.MaxAmount) > 0 {
+		return false
+	}
+	if f.Cursor != nil {
+		// Ключ (transfer_date, id) должен быть строго меньше курсора
+		if t.TransferDate.After(f.Cursor.TransferDate) {
+			return false
+		}
+		if t.TransferDate.Equal(f.Cursor.TransferDate) && slices.Compare(t.Id[:], f.Cursor.Id[:]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateAPIKey сохраняет ключ; domain.ErrWalletNotFound, если одного из кошельков нет.
+func (s *Store) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, walletId := range key.Wallets {
+		if _, err := s.wallet(walletId); err != nil {
+			return err
+		}
+	}
+	for _, k := range s.apiKeys {
+		if k.Id == key.Id || k.Hash == key.Hash {
+			return fmt.Errorf("api key %s already exists", key.Id)
+		}
+	}
+
+	key.CreatedAt = now()
+	if key.Wallets == nil {
+		key.Wallets = []uuid.UUID{}
+	}
+	c := copyAPIKey(key)
+	slices.SortFunc(c.Wallets, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	s.apiKeys = append(s.apiKeys, &c)
+	return nil
+}
+
+// GetAPIKeyByHash возвращает ключ, в том числе отозванный, по хешу или domain.ErrAPIKeyNotFound.
+func (s *Store) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.apiKeys {
+		if k.Hash == hash {
+			c := copyAPIKey(k)
+			return &c, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+// ListAPIKeys возвращает все ключи, от новых к старым.
+func (s *Store) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]model.APIKey, 0, len(s.apiKeys))
+	for i := len(s.apiKeys) - 1; i >= 0; i-- {
+		keys = append(keys, copyAPIKey(s.apiKeys[i]))
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время первого.
+func (s *Store) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.Id == id {
+			if k.RevokedAt == nil {
+				ts := now()
+				k.RevokedAt = &ts
+			}
+			c := copyAPIKey(k)
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage/storagetest"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Stores {
+		s := NewStore()
+		return storagetest.Stores{Wallets: s, Transactions: s}
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	s := NewStore()
+	a := storagetest.NewWallet(t, s, "10")
+
+	if err := s.CreateAPIKey(t.Context(), &model.APIKey{Id: uuid.New(), Hash: "h0", Role: model.APIKeyClient, Wallets: []uuid.UUID{uuid.New()}}); !errors.Is(err, domain.ErrWalletNotFound) {
+		t.Fatalf("CreateAPIKey with an unknown wallet: %v, want ErrWalletNotFound", err)
+	}
+
+	key := model.APIKey{Id: uuid.New(), Name: "shop", Hash: "h1", Role: model.APIKeyClient, Wallets: []uuid.UUID{a}}
+	if err := s.CreateAPIKey(t.Context(), &key); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	got, err := s.GetAPIKeyByHash(t.Context(), "h1")
+	if err != nil || got.Id != key.Id || len(got.Wallets) != 1 || got.Wallets[0] != a || got.RevokedAt != nil {
+		t.Fatalf("GetAPIKeyByHash = %+v, %v", got, err)
+	}
+	if _, err := s.GetAPIKeyByHash(t.Context(), "missing"); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("GetAPIKeyByHash(missing): %v, want ErrAPIKeyNotFound", err)
+	}
+
+	revoked, err := s.RevokeAPIKey(t.Context(), key.Id)
+	if err != nil || revoked.RevokedAt == nil {
+		t.Fatalf("RevokeAPIKey = %+v, %v", revoked, err)
+	}
+	again, err := s.RevokeAPIKey(t.Context(), key.Id)
+	if err != nil || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("repeated RevokeAPIKey changed revoked_at: %v, %v", again.RevokedAt, err)
+	}
+	if _, err := s.RevokeAPIKey(t.Context(), uuid.New()); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey(unknown): %v, want ErrAPIKeyNotFound", err)
+	}
+
+	keys, err := s.ListAPIKeys(t.Context())
+	if err != nil || len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Errorf("ListAPIKeys = %+v, %v", keys, err)
+	}
+}
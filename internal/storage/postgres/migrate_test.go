@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	_ "embed"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/model"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// legacyInit — схема прежнего migration/init.sql, созданная до появления миграций.
+//
+//go:embed testdata/legacy_init.sql
+var legacyInit string
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	// Версии идут подряд с единицы, чтобы пропуск файла не остался незамеченным
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration #%d has version %d, want %d", i, m.Version, i+1)
+		}
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"migrations/0001_init.up.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"bad name", fstest.MapFS{
+			"migrations/init.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"conflicting names", fstest.MapFS{
+			"migrations/0001_init.up.sql":    {Data: []byte("SELECT 1")},
+			"migrations/0001_other.down.sql": {Data: []byte("SELECT 1")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.files); err == nil {
+				t.Error("loadMigrations succeeded, want error")
+			}
+		})
+	}
+}
+
+// legacyDB создаёт отдельную базу со схемой legacyInit и удаляет её после теста.
+// Тест пропускается, если не задана переменная окружения TEST_DB_HOST.
+func legacyDB(t *testing.T) *PgDB {
+	t.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set, skipping PostgreSQL migration tests")
+	}
+	cfg := config.Config{DbConfig: config.DbConfig{
+		Host:     host,
+		Port:     envOr("TEST_DB_PORT", "5432"),
+		User:     envOr("TEST_DB_USER", "postgres"),
+		Password: envOr("TEST_DB_PASSWORD", "postgres"),
+		DbName:   envOr("TEST_DB_NAME", "wallet-db"),
+		SSLMode:  "disable",
+	}}
+	cfg.SetDefaults()
+
+	admin, err := Open(cfg.DbConfig)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	name := "legacy_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("CREATE DATABASE: %v", err)
+	}
+
+	cfg.DbConfig.DbName = name
+	db, err := Open(cfg.DbConfig)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+		_, _ = admin.Exec("DROP DATABASE IF EXISTS " + name)
+		_ = admin.Close()
+	})
+
+	if _, err := db.Exec(legacyInit); err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+	return db
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func TestMigrateLegacySchema(t *testing.T) {
+	db := legacyDB(t)
+	a, b := uuid.New(), uuid.New()
+	if _, err := db.Exec("INSERT INTO wallets (id, balance, date_update) VALUES ($1, 90.12345678, NOW()), ($2, 10, NOW())", a, b); err != nil {
+		t.Fatalf("insert wallets: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO transactions (id, from_wallet, to_wallet, amount, transfer_date) VALUES ($1, $2, $3, 9.87654321, NOW())", uuid.New(), a, b); err != nil {
+		t.Fatalf("insert transaction: %v", err)
+	}
+
+	migrator, err := NewMigrator(db.DB)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(t.Context()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	columns := map[string]string{"wallets": "balance", "transactions": "amount"}
+	for table, column := range columns {
+		var precision, scale int
+		query := "SELECT numeric_precision, numeric_scale FROM information_schema.columns WHERE table_name = $1 AND column_name = $2"
+		if err := db.QueryRow(query, table, column).Scan(&precision, &scale); err != nil {
+			t.Fatalf("%s.%s type: %v", table, column, err)
+		}
+		if precision != 38 || scale != 8 {
+			t.Errorf("%s.%s is NUMERIC(%d, %d), want NUMERIC(38, 8)", table, column, precision, scale)
+		}
+	}
+
+	var balance model.Money
+	if err := db.QueryRow("SELECT balance FROM wallets WHERE id = $1", a).Scan(&balance); err != nil || balance.Cmp(model.MustParseMoney("90.12345678")) != 0 {
+		t.Errorf("balance after migration = %s, %v; want 90.12345678", balance, err)
+	}
+}
+
+func TestMigrateLegacySchemaRejectsExtraPrecision(t *testing.T) {
+	db := legacyDB(t)
+	if _, err := db.Exec("INSERT INTO wallets (id, balance, date_update) VALUES ($1, 1.123456789, NOW())", uuid.New()); err != nil {
+		t.Fatalf("insert wallet: %v", err)
+	}
+
+	migrator, err := NewMigrator(db.DB)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, err := migrator.Up(t.Context()); err == nil || !strings.Contains(err.Error(), "constrain_money_precision") {
+		t.Fatalf("Up error = %v, want failed constrain_money_precision migration", err)
+	}
+
+	// Сумма не округлена, а миграция не отмечена применённой
+	var balance string
+	if err := db.QueryRow("SELECT balance::text FROM wallets").Scan(&balance); err != nil || balance != "1.123456789" {
+		t.Errorf("balance = %s, %v; want unchanged 1.123456789", balance, err)
+	}
+	if version, err := migrator.Version(t.Context()); err != nil || version != 7 {
+		t.Errorf("Version = %d, %v; want 7", version, err)
+	}
+}
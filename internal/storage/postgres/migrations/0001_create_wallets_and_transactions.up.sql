@@ -0,0 +1,14 @@
+-- IF NOT EXISTS оставлен для баз, созданных прежним init.sql до появления schema_migrations
+CREATE TABLE IF NOT EXISTS wallets (
+    id UUID PRIMARY KEY,
+    balance NUMERIC(38, 8) NOT NULL,
+    date_update TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id UUID PRIMARY KEY,
+    from_wallet UUID REFERENCES wallets(id),
+    to_wallet UUID REFERENCES wallets(id),
+    amount NUMERIC(38, 8) NOT NULL,
+    transfer_date TIMESTAMP NOT NULL
+);
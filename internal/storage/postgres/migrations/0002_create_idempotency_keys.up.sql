@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    created_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,37 @@
+-- Двойная запись: на каждый перевод приходится одна дебетовая (amount < 0)
+-- и одна кредитовая (amount > 0) проводка, сумма проводок перевода равна нулю.
+-- Проводка 'opening' фиксирует начальный баланс кошелька и не привязана к переводу.
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY,
+    transaction_id UUID REFERENCES transactions(id),
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    entry_type VARCHAR(16) NOT NULL,
+    amount NUMERIC(38, 8) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    CHECK (
+        (entry_type = 'debit' AND amount < 0 AND transaction_id IS NOT NULL) OR
+        (entry_type = 'credit' AND amount > 0 AND transaction_id IS NOT NULL) OR
+        (entry_type = 'opening' AND transaction_id IS NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_wallet_id_idx ON ledger_entries (wallet_id);
+CREATE INDEX IF NOT EXISTS ledger_entries_transaction_id_idx ON ledger_entries (transaction_id);
+
+-- Перенос переводов, выполненных до появления журнала проводок
+INSERT INTO ledger_entries (id, transaction_id, wallet_id, entry_type, amount, created_at)
+SELECT gen_random_uuid(), t.id, e.wallet_id, e.entry_type, e.amount, t.transfer_date
+FROM transactions t
+CROSS JOIN LATERAL (VALUES
+    (t.from_wallet, 'debit', -t.amount),
+    (t.to_wallet, 'credit', t.amount)
+) AS e (wallet_id, entry_type, amount)
+WHERE NOT EXISTS (SELECT 1 FROM ledger_entries l WHERE l.transaction_id = t.id);
+
+-- Начальный баланс существующих кошельков: разница между балансом и суммой перенесённых проводок
+INSERT INTO ledger_entries (id, transaction_id, wallet_id, entry_type, amount, created_at)
+SELECT gen_random_uuid(), NULL, w.id, 'opening',
+       w.balance - COALESCE((SELECT SUM(l.amount) FROM ledger_entries l WHERE l.wallet_id = w.id), 0),
+       NOW()
+FROM wallets w
+WHERE NOT EXISTS (SELECT 1 FROM ledger_entries l WHERE l.wallet_id = w.id AND l.entry_type = 'opening');
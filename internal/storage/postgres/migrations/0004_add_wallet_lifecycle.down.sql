@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS wallet_status_history;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS created_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS metadata;
+ALTER TABLE wallets DROP COLUMN IF EXISTS owner_ref;
+ALTER TABLE wallets DROP COLUMN IF EXISTS status;
@@ -0,0 +1,18 @@
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'frozen', 'closed'));
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS owner_ref VARCHAR(255);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- История смены статусов кошельков (заморозка, разморозка, закрытие)
+CREATE TABLE IF NOT EXISTS wallet_status_history (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    old_status VARCHAR(16) NOT NULL,
+    new_status VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_status_history_wallet_id_idx ON wallet_status_history (wallet_id, changed_at);
@@ -0,0 +1,3 @@
+-- Индексы для постраничной выборки истории кошелька по ключу (transfer_date, id)
+CREATE INDEX IF NOT EXISTS transactions_from_wallet_idx ON transactions (from_wallet, transfer_date DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_to_wallet_idx ON transactions (to_wallet, transfer_date DESC, id DESC);
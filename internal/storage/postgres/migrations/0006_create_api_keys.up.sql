@@ -0,0 +1,16 @@
+-- Ключи API: хранится только SHA-256 хеш ключа, сам ключ показывается один раз при выпуске
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('client', 'admin')),
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+-- Кошельки, доступные ключу с ролью client
+CREATE TABLE IF NOT EXISTS api_key_wallets (
+    key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    PRIMARY KEY (key_id, wallet_id)
+);
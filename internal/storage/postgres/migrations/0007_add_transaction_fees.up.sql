@@ -0,0 +1,2 @@
+-- Комиссия перевода: списывается с отправителя сверх amount и зачисляется на кошелёк для комиссий
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee NUMERIC(38, 8) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Ограничение точности не снимается: 0001 создаёт эти столбцы как NUMERIC(38, 8),
+-- и откат не должен возвращать базу к схеме прежнего init.sql
+SELECT 1;
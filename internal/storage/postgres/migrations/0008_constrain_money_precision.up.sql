@@ -0,0 +1,20 @@
+-- Базы, созданные прежним init.sql, хранят суммы в NUMERIC без ограничения точности:
+-- CREATE TABLE IF NOT EXISTS в 0001 существующие таблицы не меняет.
+-- model.Money читает не больше 30 знаков до и 8 знаков после запятой, поэтому суммы,
+-- которые не помещаются в NUMERIC(38, 8), не округляются молча, а останавливают миграцию
+-- до ручного исправления.
+DO $$
+DECLARE
+    bad_balances BIGINT;
+    bad_amounts BIGINT;
+BEGIN
+    SELECT COUNT(*) INTO bad_balances FROM wallets WHERE balance <> ROUND(balance, 8) OR ABS(balance) >= 1e30;
+    SELECT COUNT(*) INTO bad_amounts FROM transactions WHERE amount <> ROUND(amount, 8) OR ABS(amount) >= 1e30;
+    IF bad_balances > 0 OR bad_amounts > 0 THEN
+        RAISE EXCEPTION 'values do not fit NUMERIC(38, 8): % wallet balances, % transaction amounts', bad_balances, bad_amounts
+            USING HINT = 'round them to 8 decimal places before upgrading';
+    END IF;
+END $$;
+
+ALTER TABLE wallets ALTER COLUMN balance TYPE NUMERIC(38, 8);
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(38, 8);
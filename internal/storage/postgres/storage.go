@@ -1,12 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"github.com/google/uuid"
 	"golang-server/internal/config"
-	"log"
-	"os"
 	"sync"
 
 	_ "github.com/lib/pq"
@@ -21,13 +19,52 @@ var (
 // Используется для централизованного управления подключением к PostgreSQL.
 type PgDB struct {
 	*sql.DB
+	config config.DbConfig
+}
+
+// Config возвращает настройки базы данных, с которыми был создан экземпляр.
+func (db *PgDB) Config() config.DbConfig {
+	return db.config
+}
+
+// Open создаёт подключение к базе данных по конфигурации и проверяет его с помощью Ping.
+// Миграции не применяются; используется командой migrate и функцией GetInstance.
+// Параметры:
+//   - cfg: настройки базы данных.
+//
+// Возвращает:
+//   - *PgDB: открытое подключение.
+//   - error: ошибку при открытии или проверке соединения.
+func Open(cfg config.DbConfig) (*PgDB, error) {
+	// Столбцы времени хранятся как TIMESTAMP без часового пояса и заполняются NOW(),
+	// поэтому сессия работает в UTC независимо от настроек сервера базы данных
+	connStr := fmt.Sprintf("user=%s password=%s port=%s dbname=%s sslmode=%s host=%s timezone=UTC",
+		cfg.User,
+		cfg.Password,
+		cfg.Port,
+		cfg.DbName,
+		cfg.SSLMode,
+		cfg.Host,
+	)
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+
+	return &PgDB{DB: db, config: cfg}, nil
 }
 
 // GetInstance возвращает singleton-экземпляр PgDB.
 // При первом вызове:
-//  1. Создает подключение к базе данных по конфигурации.
-//  2. Проверяет соединение с помощью Ping.
-//  3. Выполняет SQL init-скрипт, если он указан в конфигурации.
+//  1. Открывает подключение к базе данных через Open.
+//  2. Применяет недостающие миграции схемы, если в конфигурации не задан skip_migrations.
 //
 // Параметры:
 //   - configuration: конфигурация приложения с настройками базы данных.
@@ -39,96 +76,32 @@ func GetInstance(configuration *config.Config) (*PgDB, error) {
 	var err error
 	cfg := configuration.DbConfig
 	once.Do(func() {
-		connStr := fmt.Sprintf("user=%s password=%s port=%s dbname=%s sslmode=%s host=%s",
-			cfg.User,
-			cfg.Password,
-			cfg.Port,
-			cfg.DbName,
-			cfg.SSLMode,
-			cfg.Host,
-		)
-
-		db, e := sql.Open("postgres", connStr)
+		db, e := Open(cfg)
 		if e != nil {
-			err = fmt.Errorf("failed to open db connection: %w", e)
+			err = e
 			return
 		}
 
-		if e = db.Ping(); e != nil {
-			err = fmt.Errorf("failed to ping db: %w", e)
-			return
-		}
-
-		if err = ExecuteInitScripts(db, configuration); err != nil {
-			_ = db.Close()
-			err = fmt.Errorf("init scripts error: %w", err)
-			return
+		if !cfg.SkipMigrations {
+			if err = migrate(db.DB); err != nil {
+				_ = db.Close()
+				err = fmt.Errorf("migrations error: %w", err)
+				return
+			}
 		}
 
-		postgresInstance = &PgDB{db}
+		postgresInstance = db
 	})
 
 	return postgresInstance, err
 }
 
-// ExecuteInitScripts выполняет SQL-инициализацию базы данных.
-// 1. Читает SQL-скрипт из файла, указанного в конфигурации.
-// 2. Выполняет SQL-операции.
-// 3. Проверяет наличие данных в таблице wallets и добавляет дефолтный кошелек, если таблица пустая.
-// Параметры:
-//   - db: открытое подключение к базе данных.
-//   - configuration: конфигурация с путем к init-скрипту.
-//
-// Возвращает:
-//   - error: ошибку при чтении файла, выполнении скрипта или вставке данных.
-func ExecuteInitScripts(db *sql.DB, configuration *config.Config) error {
-	cfg := configuration.DbConfig
-	sqlBytes, err := os.ReadFile(cfg.InitScript)
-	if err != nil {
-		return fmt.Errorf("failed to read SQL file: %w", err)
-	}
-
-	sqlStatements := string(sqlBytes)
-
-	_, err = db.Exec(sqlStatements)
-	if err != nil {
-		return fmt.Errorf("failed to execute SQL script: %w", err)
-	}
-
-	var count int
-	selectQuery := "SELECT COUNT(*) FROM wallets"
-	err = db.QueryRow(selectQuery).Scan(&count)
-	if err != nil {
-		return fmt.Errorf("failed to query wallets count: %w", err)
-	}
-
-	if count == 0 {
-		for range 10 {
-			if err := insertWallet(uuid.New(), db); err != nil {
-				return fmt.Errorf("failed to insert wallet: %w", err)
-			}
-		}
-	}
-	return nil
-}
-
-// insertWallet добавляет дефолтный кошелек в таблицу wallets.
-// Значения по умолчанию: id = 1, balance = 10000.
-// Используется внутри ExecuteInitScripts.
-// Параметры:
-//   - db: открытое подключение к базе данных.
-//
-// Возвращает:
-//   - error: ошибку при вставке данных.
-func insertWallet(id uuid.UUID, db *sql.DB) error {
-	const initialBalance = 100.0
-	_, err := db.Exec(
-		"INSERT INTO wallets (id, balance, date_update) VALUES ($1, $2, NOW())",
-		id, initialBalance,
-	)
+// migrate применяет все недостающие миграции схемы.
+func migrate(db *sql.DB) error {
+	migrator, err := NewMigrator(db)
 	if err != nil {
 		return err
 	}
-	log.Println("Inserted default wallet:", id)
-	return nil
+	_, err = migrator.Up(context.Background())
+	return err
 }
@@ -1,147 +1,395 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang-server/internal/domain"
+	"golang-server/internal/limits"
 	"golang-server/internal/model"
 	"golang-server/internal/storage/postgres"
-	"math/big"
+	"slices"
+	"strings"
+	"time"
 )
 
 // TransactionRepository управляет транзакциями между кошельками.
 type TransactionRepository struct {
-	db *postgres.PgDB
+	db           *postgres.PgDB
+	runner       *TxRunner
+	readTimeout  time.Duration
+	writeTimeout time.Duration
 }
 
 // WalletRepository управляет данными кошельков.
 type WalletRepository struct {
-	db *postgres.PgDB
+	db           *postgres.PgDB
+	runner       *TxRunner
+	readTimeout  time.Duration
+	writeTimeout time.Duration
 }
 
 // NewTransactionRepository создаёт новый репозиторий транзакций.
 func NewTransactionRepository(db *postgres.PgDB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+	cfg := db.Config()
+	return &TransactionRepository{
+		db:           db,
+		runner:       NewTxRunner(db, cfg),
+		readTimeout:  time.Duration(cfg.ReadTimeout),
+		writeTimeout: time.Duration(cfg.WriteTimeout),
+	}
 }
 
 // NewWalletRepository создаёт новый репозиторий кошельков.
 func NewWalletRepository(db *postgres.PgDB) *WalletRepository {
-	return &WalletRepository{db: db}
+	cfg := db.Config()
+	return &WalletRepository{
+		db:           db,
+		runner:       NewTxRunner(db, cfg),
+		readTimeout:  time.Duration(cfg.ReadTimeout),
+		writeTimeout: time.Duration(cfg.WriteTimeout),
+	}
+}
+
+// CountWallets возвращает количество кошельков.
+// Возвращает:
+//   - int: количество кошельков.
+//   - error: ошибку при выполнении запроса.
+func (wr *WalletRepository) CountWallets(ctx context.Context) (_ int, err error) {
+	ctx, done := OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	var count int
+	if err := wr.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM wallets").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to query wallets count: %w", err)
+	}
+	return count, nil
 }
 
 // GetBalance возвращает текущий баланс кошелька по его ID.
 // Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
 //   - walletId: идентификатор кошелька.
 //
 // Возвращает:
-//   - *big.Float: баланс кошелька.
+//   - model.Money: баланс кошелька.
 //   - error: ошибку при выполнении запроса.
-func (wr *WalletRepository) GetBalance(walletId uuid.UUID) (*big.Float, error) {
+func (wr *WalletRepository) GetBalance(ctx context.Context, walletId uuid.UUID) (_ model.Money, err error) {
+	ctx, done := OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
 	db := wr.db
 
 	// Начинаем транзакцию с Repeatable Read
-	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 		ReadOnly:  true,
 	})
 	if err != nil {
-		return nil, err
+		return model.Money{}, err
 	}
 	defer func() {
 		_ = tx.Rollback() // безопасно вызвать Rollback даже после Commit
 	}()
 
 	getBalanceQuery := "SELECT balance FROM wallets WHERE id = $1;"
-	var balance float64
-	err = tx.QueryRow(getBalanceQuery, walletId).Scan(&balance)
+	var balance model.Money
+	err = tx.QueryRowContext(ctx, getBalanceQuery, walletId).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Money{}, fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+	}
 	if err != nil {
-		return nil, err
+		return model.Money{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, err
+		return model.Money{}, err
 	}
 
-	return big.NewFloat(balance), nil
+	return balance, nil
 }
 
 // SendMoney переводит деньги между кошельками в рамках транзакции.
+// Комиссия data.Fee списывается с отправителя сверх суммы и зачисляется на data.FeeWallet в той же транзакции.
 // Уровень изоляции: Serializable для предотвращения проблем с конкурентным доступом (Race Conditions, Phantom Reads).
+// Конфликты сериализации и взаимоблокировки автоматически повторяются (см. TxRunner).
 // Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
 //   - data: структура TransferMoneyRequest с информацией о переводе.
 //
 // Возвращает:
-//   - *uuid.UUID: ID созданной транзакции.
-//   - error: ошибку при выполнении транзакции.
-func (tr *TransactionRepository) SendMoney(data model.TransferMoneyRequest) (*uuid.UUID, error) {
-	db := tr.db
-	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{
+//   - *model.Transaction: созданная транзакция или исходная при повторе с тем же ключом идемпотентности.
+//   - error: ошибку при выполнении транзакции; domain.ErrWalletNotFound, если один из кошельков
+//     не существует, domain.ErrWalletFrozen или domain.ErrWalletClosed, если один из кошельков
+//     заблокирован, domain.ErrInsufficientFunds, если баланса отправителя не хватает на сумму
+//     с комиссией, доменную ошибку нарушенного лимита, если перевод превышает data.Limits.
+func (tr *TransactionRepository) SendMoney(ctx context.Context, data model.TransferMoneyRequest) (_ *model.Transaction, err error) {
+	ctx, done := OperationContext(ctx, tr.writeTimeout)
+	defer done(&err)
+
+	var transaction *model.Transaction
+	opts := &sql.TxOptions{
 		Isolation: sql.LevelSerializable, // Высокий уровень изоляции предотвращает конкурентные конфликты
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	defer func() {
-		if p := recover(); p != nil {
-			err := tx.Rollback()
+	// Конфликты сериализации и взаимоблокировки повторяются TxRunner'ом
+	err = tr.runner.Run(ctx, opts, func(ctx context.Context, sqlTx *sql.Tx) error {
+		// Каждый запрос перевода выполняется в своём span'е трассировки
+		tx := TracedTx{Tx: sqlTx, System: "postgresql"}
+
+		// Повтор запроса с тем же Idempotency-Key возвращает исходную транзакцию без нового перевода.
+		// Гонку двух запросов с одним ключом разрешает Serializable: проигравший получит
+		// ошибку сериализации, будет повторён и увидит уже сохранённый ключ.
+		if data.IdempotencyKey != "" {
+			existingId, err := findIdempotencyKey(ctx, tx, data)
 			if err != nil {
-				return
+				return err
 			}
-			panic(p)
-		} else if err != nil {
-			err := tx.Rollback()
-			if err != nil {
-				return
+			if existingId != nil {
+				if transaction, err = getTransaction(ctx, tx, *existingId); err != nil {
+					return err
+				}
+				transaction.Replayed = true
+				return nil
 			}
 		}
-	}()
 
-	// Вставка новой транзакции
-	sendQuery := "INSERT INTO transactions (id, from_wallet, to_wallet, amount, transfer_date) VALUES ($1, $2, $3, $4, NOW())"
-	transactionId := uuid.New()
-	_, err = tx.Exec(sendQuery, transactionId, data.From, data.To, data.Amount)
+		// Проверка статусов и баланса. FOR UPDATE блокирует строки кошельков до конца транзакции,
+		// поэтому параллельный перевод не сможет списать те же средства, а заморозка — проскочить между проверкой и списанием.
+		// Строки блокируются одним запросом в порядке ID, поэтому встречные переводы не взаимоблокируются.
+		wallets, err := lockWallets(ctx, tx, TransferWalletIds(data))
+		if err != nil {
+			return err
+		}
+		if err := CheckTransferWallets(data, wallets); err != nil {
+			return err
+		}
+		if err := checkLimits(ctx, tx, data); err != nil {
+			return err
+		}
+		if wallets[data.From].Balance.Cmp(data.Amount.Add(data.Fee)) < 0 {
+			return domain.ErrInsufficientFunds
+		}
+
+		// Увеличение баланса получателя. Выполняется до вставки транзакции,
+		// чтобы несуществующий получатель давал доменную ошибку, а не нарушение внешнего ключа.
+		updateQuery := "UPDATE wallets SET balance = balance + $1, date_update = NOW() WHERE id = $2"
+		if err := execOne(ctx, tx, data.To, updateQuery, data.Amount, data.To); err != nil {
+			return err
+		}
+
+		// Уменьшение баланса отправителя на сумму с комиссией
+		updateQuery = "UPDATE wallets SET balance = balance - $1, date_update = NOW() WHERE id = $2"
+		if err := execOne(ctx, tx, data.From, updateQuery, data.Amount.Add(data.Fee), data.From); err != nil {
+			return err
+		}
+
+		// Зачисление комиссии
+		if data.Fee.Sign() > 0 {
+			updateQuery = "UPDATE wallets SET balance = balance + $1, date_update = NOW() WHERE id = $2"
+			if err := execOne(ctx, tx, data.FeeWallet, updateQuery, data.Fee, data.FeeWallet); err != nil {
+				return err
+			}
+		}
+
+		// Вставка новой транзакции
+		transaction = &model.Transaction{Id: uuid.New(), From: data.From, To: data.To, Amount: data.Amount, Fee: data.Fee}
+		sendQuery := "INSERT INTO transactions (id, from_wallet, to_wallet, amount, fee, transfer_date) VALUES ($1, $2, $3, $4, $5, NOW() AT TIME ZONE 'UTC') RETURNING transfer_date"
+		err = tx.QueryRowContext(ctx, sendQuery, transaction.Id, data.From, data.To, data.Amount, data.Fee).Scan(&transaction.TransferDate)
+		if err != nil {
+			return err
+		}
+
+		// Проводки двойной записи с проверкой, что их сумма равна нулю
+		if err := postTransfer(ctx, tx, transaction.Id, data); err != nil {
+			return err
+		}
+		if data.IdempotencyKey == "" {
+			return nil
+		}
+
+		// Сохранение ключа идемпотентности в той же транзакции, что и перевод
+		keyQuery := "INSERT INTO idempotency_keys (key, request_hash, transaction_id, created_at) VALUES ($1, $2, $3, NOW())"
+		_, err = tx.ExecContext(ctx, keyQuery, data.IdempotencyKey, data.Fingerprint(), transaction.Id)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Увеличение баланса получателя
-	updateQuery := "UPDATE wallets SET balance = balance + $1, date_update = NOW() WHERE id = $2"
-	_, err = tx.Exec(updateQuery, data.Amount, data.To)
-	if err != nil {
-		return nil, err
+	return transaction, nil
+}
+
+// CheckTransferable проверяет, что кошелёк в статусе status может участвовать в переводе.
+// Замороженный кошелёк не может ни отправлять, ни получать средства, закрытый — тоже.
+func CheckTransferable(walletId uuid.UUID, status model.WalletStatus) error {
+	switch status {
+	case model.WalletFrozen:
+		return fmt.Errorf("%w: %s", domain.ErrWalletFrozen, walletId)
+	case model.WalletClosed:
+		return fmt.Errorf("%w: %s", domain.ErrWalletClosed, walletId)
+	}
+	return nil
+}
+
+// TransferWalletIds возвращает кошельки перевода — отправителя, получателя и, если взимается
+// комиссия, кошелёк для комиссий — без повторов в порядке возрастания ID.
+// Хранилища блокируют кошельки перевода в этом порядке, поэтому встречные переводы не взаимоблокируются.
+func TransferWalletIds(data model.TransferMoneyRequest) []uuid.UUID {
+	ids := []uuid.UUID{data.From, data.To}
+	if data.Fee.Sign() > 0 {
+		ids = append(ids, data.FeeWallet)
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	return slices.Compact(ids)
+}
+
+// CheckTransferWallets проверяет, что все кошельки перевода найдены в wallets и могут в нём участвовать.
+// Возвращает domain.ErrWalletNotFound, domain.ErrWalletFrozen или domain.ErrWalletClosed
+// для первого неподходящего кошелька в порядке: отправитель, получатель, кошелёк для комиссий.
+func CheckTransferWallets(data model.TransferMoneyRequest, wallets map[uuid.UUID]*model.Wallet) error {
+	ids := []uuid.UUID{data.From, data.To}
+	if data.Fee.Sign() > 0 {
+		ids = append(ids, data.FeeWallet)
 	}
+	for i, id := range ids {
+		wallet, ok := wallets[id]
+		switch {
+		case !ok && i == 2:
+			return fmt.Errorf("%w: fee collection wallet %s", domain.ErrWalletNotFound, id)
+		case !ok:
+			return fmt.Errorf("%w: %s", domain.ErrWalletNotFound, id)
+		}
+		if err := CheckTransferable(id, wallet.Status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// Уменьшение баланса отправителя
-	updateQuery = "UPDATE wallets SET balance = balance - $1, date_update = NOW() WHERE id = $2"
-	_, err = tx.Exec(updateQuery, data.Amount, data.From)
+// lockWallets блокирует строки кошельков ids одним запросом в порядке ID и возвращает их
+// статусы и балансы. Отсутствующие кошельки в результат не попадают.
+func lockWallets(ctx context.Context, tx Querier, ids []uuid.UUID) (map[uuid.UUID]*model.Wallet, error) {
+	params := make([]string, len(ids))
+	for i, id := range ids {
+		params[i] = id.String()
+	}
+
+	query := "SELECT id, status, balance FROM wallets WHERE id = ANY($1::uuid[]) ORDER BY id FOR UPDATE"
+	rows, err := tx.QueryContext(ctx, query, pq.Array(params))
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		_ = rows.Close()
+	}()
 
-	if err := tx.Commit(); err != nil {
+	wallets := make(map[uuid.UUID]*model.Wallet, len(ids))
+	for rows.Next() {
+		var w model.Wallet
+		if err := rows.Scan(&w.Id, &w.Status, &w.Balance); err != nil {
+			return nil, err
+		}
+		wallets[w.Id] = &w
+	}
+	return wallets, rows.Err()
+}
+
+// getTransaction читает перевод по ID.
+func getTransaction(ctx context.Context, tx Querier, id uuid.UUID) (*model.Transaction, error) {
+	var t model.Transaction
+	query := "SELECT id, from_wallet, to_wallet, amount, fee, transfer_date FROM transactions WHERE id = $1"
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&t.Id, &t.From, &t.To, &t.Amount, &t.Fee, &t.TransferDate); err != nil {
 		return nil, err
 	}
+	return &t, nil
+}
+
+// checkLimits проверяет перевод по лимитам отправителя data.Limits.
+// Строка отправителя уже заблокирована FOR UPDATE, поэтому параллельные переводы
+// с того же кошелька не изменят суммы между подсчётом и списанием.
+func checkLimits(ctx context.Context, tx Querier, data model.TransferMoneyRequest) error {
+	if !limits.NeedsUsage(data.Limits) {
+		return limits.CheckAmount(data.Limits, data.Amount)
+	}
+
+	// Периоды считаются по часам базы данных в UTC — так же, как записывается transfer_date
+	var usage model.OutgoingUsage
+	usageQuery := `WITH w AS (SELECT NOW() AT TIME ZONE 'UTC' AS now)
+	SELECT
+		COALESCE(SUM(amount) FILTER (WHERE transfer_date >= date_trunc('day', w.now)), 0),
+		COALESCE(SUM(amount) FILTER (WHERE transfer_date >= date_trunc('month', w.now)), 0),
+		COUNT(*) FILTER (WHERE transfer_date >= w.now - INTERVAL '1 hour')
+	FROM transactions, w
+	WHERE from_wallet = $1 AND transfer_date >= LEAST(date_trunc('month', w.now), w.now - INTERVAL '1 hour')`
+	err := tx.QueryRowContext(ctx, usageQuery, data.From).Scan(&usage.Daily, &usage.Monthly, &usage.HourCount)
+	if err != nil {
+		return err
+	}
+	return limits.Check(data.Limits, data.Amount, usage)
+}
 
+// findIdempotencyKey ищет сохранённый ключ идемпотентности запроса.
+// Возвращает ID исходной транзакции, nil, если ключ не использовался,
+// или domain.ErrIdempotencyConflict, если ключ сохранён с другим отпечатком запроса.
+func findIdempotencyKey(ctx context.Context, tx Querier, data model.TransferMoneyRequest) (*uuid.UUID, error) {
+	var requestHash string
+	var transactionId uuid.UUID
+	query := "SELECT request_hash, transaction_id FROM idempotency_keys WHERE key = $1"
+	err := tx.QueryRowContext(ctx, query, data.IdempotencyKey).Scan(&requestHash, &transactionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if requestHash != data.Fingerprint() {
+		return nil, domain.ErrIdempotencyConflict
+	}
 	return &transactionId, nil
 }
 
+// execOne выполняет UPDATE по кошельку walletId и проверяет, что была затронута ровно одна строка.
+// Если строк не затронуто, возвращает domain.ErrWalletNotFound.
+func execOne(ctx context.Context, tx Querier, walletId uuid.UUID, query string, args ...any) error {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+	}
+	return nil
+}
+
 // GetLastTransactions возвращает последние N транзакций.
 // Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
 //   - numberOfTx: количество последних транзакций для получения.
 //
 // Возвращает:
 //   - []model.Transaction: массив транзакций.
 //   - error: ошибку при выполнении запроса.
-func (tr *TransactionRepository) GetLastTransactions(numberOfTx int) ([]model.Transaction, error) {
+func (tr *TransactionRepository) GetLastTransactions(ctx context.Context, numberOfTx int) (_ []model.Transaction, err error) {
+	ctx, done := OperationContext(ctx, tr.readTimeout)
+	defer done(&err)
+
 	query :=
-		`SELECT id, from_wallet, to_wallet, amount, transfer_date
+		`SELECT id, from_wallet, to_wallet, amount, fee, transfer_date
 	FROM transactions
-	ORDER BY transfer_date DESC 
+	ORDER BY transfer_date DESC, id DESC
 	LIMIT $1;`
 
-	rows, err := tr.db.Query(query, numberOfTx)
+	rows, err := tr.db.QueryContext(ctx, query, numberOfTx)
 	if err != nil {
 		return nil, err
 	}
@@ -156,27 +404,127 @@ func (tr *TransactionRepository) GetLastTransactions(numberOfTx int) ([]model.Tr
 
 	for rows.Next() {
 		var transaction model.Transaction
-		var amountStr string
 
 		err := rows.Scan(
 			&transaction.Id,
-			&transaction.To,
 			&transaction.From,
-			&amountStr,
+			&transaction.To,
+			&transaction.Amount,
+			&transaction.Fee,
 			&transaction.TransferDate,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		amount := new(big.Float)
-		_, ok := amount.SetString(amountStr)
-		if !ok {
-			return nil, fmt.Errorf("failed to parse amount: %s", amountStr)
+		transactions = append(transactions, transaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// walletColumns — список столбцов кошелька в порядке, ожидаемом scanWallet.
+const walletColumns = "id, balance, status, owner_ref, metadata, created_at, date_update"
+
+// scanWallet читает строку с walletColumns в model.Wallet.
+func scanWallet(row interface{ Scan(dest ...any) error }) (*model.Wallet, error) {
+	var wallet model.Wallet
+	var metadata []byte
+	err := row.Scan(
+		&wallet.Id,
+		&wallet.Balance,
+		&wallet.Status,
+		&wallet.OwnerRef,
+		&metadata,
+		&wallet.CreatedAt,
+		&wallet.DateUpdate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	wallet.Metadata = metadata
+	return &wallet, nil
+}
+
+// GetWalletTransactions возвращает переводы кошелька по фильтру,
+// упорядоченные по (transfer_date, id) по убыванию.
+// Постраничная выборка выполняется по ключу: курсор задаёт последнюю возвращённую запись.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - filter: кошелёк, направление, диапазоны дат и сумм, лимит и курсор.
+//
+// Возвращает:
+//   - []model.Transaction: не более filter.Limit транзакций.
+//   - error: ошибку при выполнении запроса.
+func (tr *TransactionRepository) GetWalletTransactions(ctx context.Context, filter model.TransactionFilter) (_ []model.Transaction, err error) {
+	ctx, done := OperationContext(ctx, tr.readTimeout)
+	defer done(&err)
+
+	args := []any{filter.WalletId}
+	conditions := make([]string, 0, 6)
+
+	switch filter.Direction {
+	case model.DirectionIncoming:
+		conditions = append(conditions, "to_wallet = $1")
+	case model.DirectionOutgoing:
+		conditions = append(conditions, "from_wallet = $1")
+	default:
+		conditions = append(conditions, "(from_wallet = $1 OR to_wallet = $1)")
+	}
+
+	// addCondition добавляет условие с очередным позиционным параметром
+	addCondition := func(format string, values ...any) {
+		placeholders := make([]any, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
 		}
-		transaction.Amount = *amount
+		conditions = append(conditions, fmt.Sprintf(format, placeholders...))
+	}
 
-		transactions = append(transactions, transaction)
+	if filter.FromDate != nil {
+		addCondition("transfer_date >= %s", *filter.FromDate)
+	}
+	if filter.ToDate != nil {
+		addCondition("transfer_date < %s", *filter.ToDate)
+	}
+	if filter.MinAmount != nil {
+		addCondition("amount >= %s", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("amount <= %s", *filter.MaxAmount)
+	}
+	if filter.Cursor != nil {
+		addCondition("(transfer_date, id) < (%s, %s)", filter.Cursor.TransferDate, filter.Cursor.Id)
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(
+		`SELECT id, from_wallet, to_wallet, amount, fee, transfer_date
+	FROM transactions
+	WHERE %s
+	ORDER BY transfer_date DESC, id DESC
+	LIMIT $%d;`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := tr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	transactions := make([]model.Transaction, 0, filter.Limit)
+	for rows.Next() {
+		var t model.Transaction
+		if err := rows.Scan(&t.Id, &t.From, &t.To, &t.Amount, &t.Fee, &t.TransferDate); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -188,15 +536,19 @@ func (tr *TransactionRepository) GetLastTransactions(numberOfTx int) ([]model.Tr
 
 // GetWallet возвращает информацию о кошельке по его ID.
 // Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
 //   - walletId: идентификатор кошелька.
 //
 // Возвращает:
-//   - *model.Wallet: структура кошелька с балансом и датой обновления.
-//   - error: ошибку при выполнении запроса.
-func (wr *WalletRepository) GetWallet(walletId uuid.UUID) (*model.Wallet, error) {
+//   - *model.Wallet: структура кошелька с балансом, статусом и датой обновления.
+//   - error: ошибку при выполнении запроса; domain.ErrWalletNotFound, если кошелёк не существует.
+func (wr *WalletRepository) GetWallet(ctx context.Context, walletId uuid.UUID) (_ *model.Wallet, err error) {
+	ctx, done := OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
 	db := wr.db
 
-	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 		ReadOnly:  true,
 	})
@@ -207,31 +559,148 @@ func (wr *WalletRepository) GetWallet(walletId uuid.UUID) (*model.Wallet, error)
 		_ = tx.Rollback()
 	}()
 
-	query :=
-		`SELECT
-		w.id,
-		w.balance,
-		w.date_update
-	FROM wallets w
-	WHERE w.id = $1;`
+	query := "SELECT " + walletColumns + " FROM wallets WHERE id = $1;"
 
-	var wallet model.Wallet
-	var balanceStr string
+	wallet, err := scanWallet(tx.QueryRowContext(ctx, query, walletId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
 
-	err = tx.QueryRow(query, walletId).Scan(&wallet.Id, &balanceStr, &wallet.DateUpdate)
+// CreateWallet создаёт кошелёк и проводку его начального баланса в одной транзакции.
+// Поля CreatedAt и DateUpdate заполняются значениями из базы.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - wallet: новый кошелёк; Metadata должен быть JSON-объектом или пустым.
+//
+// Возвращает:
+//   - error: ошибку при выполнении транзакции.
+func (wr *WalletRepository) CreateWallet(ctx context.Context, wallet *model.Wallet) (err error) {
+	ctx, done := OperationContext(ctx, wr.writeTimeout)
+	defer done(&err)
+
+	if len(wallet.Metadata) == 0 || string(wallet.Metadata) == "null" {
+		wallet.Metadata = json.RawMessage("{}")
+	}
+
+	return wr.runner.Run(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context, tx *sql.Tx) error {
+		insertQuery :=
+			`INSERT INTO wallets (id, balance, status, owner_ref, metadata, created_at, date_update)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		RETURNING created_at, date_update`
+		err := tx.QueryRowContext(ctx, insertQuery, wallet.Id, wallet.Balance, wallet.Status, wallet.OwnerRef, string(wallet.Metadata)).
+			Scan(&wallet.CreatedAt, &wallet.DateUpdate)
+		if err != nil {
+			return err
+		}
+
+		openingQuery := "INSERT INTO ledger_entries (id, transaction_id, wallet_id, entry_type, amount, created_at) VALUES ($1, NULL, $2, $3, $4, NOW())"
+		_, err = tx.ExecContext(ctx, openingQuery, uuid.New(), wallet.Id, model.LedgerOpening, wallet.Balance)
+		return err
+	})
+}
+
+// UpdateWalletStatus меняет статус кошелька с проверкой допустимости перехода
+// и записывает изменение в историю wallet_status_history в той же транзакции.
+// Закрыть можно только кошелёк с нулевым балансом.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - walletId: идентификатор кошелька.
+//   - change: новый статус (NewStatus), причина и инициатор изменения;
+//     остальные поля заполняются репозиторием.
+//
+// Возвращает:
+//   - *model.Wallet: кошелёк после изменения.
+//   - error: domain.ErrWalletNotFound, domain.ErrInvalidStatusTransition,
+//     domain.ErrWalletNotEmpty или ошибку при выполнении транзакции.
+func (wr *WalletRepository) UpdateWalletStatus(ctx context.Context, walletId uuid.UUID, change model.WalletStatusChange) (_ *model.Wallet, err error) {
+	ctx, done := OperationContext(ctx, wr.writeTimeout)
+	defer done(&err)
+
+	var wallet *model.Wallet
+	err = wr.runner.Run(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(ctx context.Context, tx *sql.Tx) error {
+		selectQuery := "SELECT " + walletColumns + " FROM wallets WHERE id = $1 FOR UPDATE"
+		current, err := scanWallet(tx.QueryRowContext(ctx, selectQuery, walletId))
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !current.Status.CanTransitionTo(change.NewStatus) {
+			return fmt.Errorf("%w: %s -> %s", domain.ErrInvalidStatusTransition, current.Status, change.NewStatus)
+		}
+		if change.NewStatus == model.WalletClosed && !current.Balance.IsZero() {
+			return domain.ErrWalletNotEmpty
+		}
+
+		updateQuery := "UPDATE wallets SET status = $1, date_update = NOW() WHERE id = $2 RETURNING " + walletColumns
+		wallet, err = scanWallet(tx.QueryRowContext(ctx, updateQuery, change.NewStatus, walletId))
+		if err != nil {
+			return err
+		}
+
+		historyQuery :=
+			`INSERT INTO wallet_status_history (id, wallet_id, old_status, new_status, reason, actor, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())`
+		_, err = tx.ExecContext(ctx, historyQuery, uuid.New(), walletId, current.Status, change.NewStatus, change.Reason, change.Actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// GetWalletStatusHistory возвращает историю смены статусов кошелька, от новых к старым.
+// Параметры:
+//   - ctx: контекст запроса; его отмена прерывает запрос к базе.
+//   - walletId: идентификатор кошелька.
+//
+// Возвращает:
+//   - []model.WalletStatusChange: записи истории.
+//   - error: ошибку при выполнении запроса.
+func (wr *WalletRepository) GetWalletStatusHistory(ctx context.Context, walletId uuid.UUID) (_ []model.WalletStatusChange, err error) {
+	ctx, done := OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	query :=
+		`SELECT id, wallet_id, old_status, new_status, reason, actor, changed_at
+	FROM wallet_status_history
+	WHERE wallet_id = $1
+	ORDER BY changed_at DESC, id DESC;`
+
+	rows, err := wr.db.QueryContext(ctx, query, walletId)
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		_ = rows.Close()
+	}()
 
-	balance := new(big.Float)
-	if _, ok := balance.SetString(balanceStr); !ok {
-		return nil, fmt.Errorf("failed to parse balance: %s", balanceStr)
+	history := make([]model.WalletStatusChange, 0)
+	for rows.Next() {
+		var c model.WalletStatusChange
+		if err := rows.Scan(&c.Id, &c.WalletId, &c.OldStatus, &c.NewStatus, &c.Reason, &c.Actor, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
 	}
-	wallet.Balance = *balance
 
-	if err := tx.Commit(); err != nil {
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &wallet, nil
+	return history, nil
 }
@@ -0,0 +1,178 @@
+package storage_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage"
+	"golang-server/internal/storage/postgres"
+	"golang-server/internal/storage/storagetest"
+	"os"
+	"slices"
+	"testing"
+)
+
+// Тесты репозиториев работают с настоящим PostgreSQL и пропускаются,
+// если не задана переменная окружения TEST_DB_HOST. Пример для docker-compose:
+//
+//	TEST_DB_HOST=localhost TEST_DB_PORT=5431 go test ./internal/storage/...
+func testDB(t *testing.T) *postgres.PgDB {
+	t.Helper()
+
+	host := os.Getenv("TEST_DB_HOST")
+	if host == "" {
+		t.Skip("TEST_DB_HOST is not set, skipping PostgreSQL repository tests")
+	}
+
+	cfg := &config.Config{DbConfig: config.DbConfig{
+		Host:     host,
+		Port:     envOr("TEST_DB_PORT", "5432"),
+		User:     envOr("TEST_DB_USER", "postgres"),
+		Password: envOr("TEST_DB_PASSWORD", "postgres"),
+		DbName:   envOr("TEST_DB_NAME", "wallet-db"),
+		SSLMode:  "disable",
+	}}
+	cfg.SetDefaults()
+
+	db, err := postgres.GetInstance(cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	return db
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func TestRepositories(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Stores {
+		db := testDB(t)
+		return storagetest.Stores{Wallets: storage.NewWalletRepository(db), Transactions: storage.NewTransactionRepository(db)}
+	})
+}
+
+func TestGetLastTransactionsOrdering(t *testing.T) {
+	db := testDB(t)
+	wr := storage.NewWalletRepository(db)
+	tr := storage.NewTransactionRepository(db)
+
+	a := storagetest.NewWallet(t, wr, "100")
+	b := storagetest.NewWallet(t, wr, "100")
+
+	transfers := []model.TransferMoneyRequest{
+		{From: a, To: b, Amount: model.MustParseMoney("1")},
+		{From: b, To: a, Amount: model.MustParseMoney("2")},
+		{From: a, To: b, Amount: model.MustParseMoney("3")},
+	}
+	ids := make([]uuid.UUID, len(transfers))
+	for i, req := range transfers {
+		sent, err := tr.SendMoney(t.Context(), req)
+		if err != nil {
+			t.Fatalf("SendMoney #%d: %v", i, err)
+		}
+		ids[i] = sent.Id
+	}
+
+	// База общая с другими тестами и запусками, поэтому из последних транзакций
+	// берутся только переводы между кошельками этого теста
+	recent, err := tr.GetLastTransactions(t.Context(), 100)
+	if err != nil {
+		t.Fatalf("GetLastTransactions: %v", err)
+	}
+	var last []model.Transaction
+	for _, tx := range recent {
+		if (tx.From == a || tx.From == b) && (tx.To == a || tx.To == b) {
+			last = append(last, tx)
+		}
+	}
+	if len(last) != len(transfers) {
+		t.Fatalf("GetLastTransactions returned %d transactions, want %d", len(last), len(transfers))
+	}
+
+	// Последние транзакции возвращаются от новых к старым
+	for i, got := range last {
+		j := len(transfers) - 1 - i
+		want := transfers[j]
+		if got.Id != ids[j] {
+			t.Errorf("position %d: id = %s, want %s", i, got.Id, ids[j])
+		}
+		if got.From != want.From || got.To != want.To {
+			t.Errorf("position %d: direction = %s -> %s, want %s -> %s", i, got.From, got.To, want.From, want.To)
+		}
+		if got.Amount.Cmp(want.Amount) != 0 {
+			t.Errorf("position %d: amount = %s, want %s", i, got.Amount, want.Amount)
+		}
+		if i > 0 && got.TransferDate.After(last[i-1].TransferDate) {
+			t.Errorf("position %d: transfer date %v is after previous %v", i, got.TransferDate, last[i-1].TransferDate)
+		}
+	}
+
+	storagetest.AssertBalance(t, wr, a, "98")
+	storagetest.AssertBalance(t, wr, b, "102")
+}
+
+func TestAPIKeyRoundTrip(t *testing.T) {
+	db := testDB(t)
+	wr := storage.NewWalletRepository(db)
+	kr := storage.NewAPIKeyRepository(db)
+
+	wallet := storagetest.NewWallet(t, wr, "10")
+	sum := sha256.Sum256([]byte(uuid.NewString()))
+	key := model.APIKey{Id: uuid.New(), Name: "shop", Hash: hex.EncodeToString(sum[:]), Role: model.APIKeyClient, Wallets: []uuid.UUID{wallet}}
+	if err := kr.CreateAPIKey(t.Context(), &key); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	got, err := kr.GetAPIKeyByHash(t.Context(), key.Hash)
+	if err != nil || got.Id != key.Id || len(got.Wallets) != 1 || got.Wallets[0] != wallet {
+		t.Fatalf("GetAPIKeyByHash = %+v, %v", got, err)
+	}
+
+	revoked, err := kr.RevokeAPIKey(t.Context(), key.Id)
+	if err != nil || revoked.RevokedAt == nil {
+		t.Fatalf("RevokeAPIKey = %+v, %v", revoked, err)
+	}
+	if _, err := kr.RevokeAPIKey(t.Context(), uuid.New()); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey(unknown): %v, want ErrAPIKeyNotFound", err)
+	}
+}
+
+func TestTransferWalletIds(t *testing.T) {
+	a := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	b := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	c := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+
+	tests := []struct {
+		name string
+		data model.TransferMoneyRequest
+		want []uuid.UUID
+	}{
+		{"sorted", model.TransferMoneyRequest{From: c, To: a}, []uuid.UUID{a, c}},
+		{"fee wallet", model.TransferMoneyRequest{From: c, To: a, Fee: model.MustParseMoney("1"), FeeWallet: b}, []uuid.UUID{a, b, c}},
+		{"no fee", model.TransferMoneyRequest{From: c, To: a, FeeWallet: b}, []uuid.UUID{a, c}},
+		{"fee to recipient", model.TransferMoneyRequest{From: b, To: a, Fee: model.MustParseMoney("1"), FeeWallet: a}, []uuid.UUID{a, b}},
+	}
+	for _, tt := range tests {
+		if got := storage.TransferWalletIds(tt.data); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: TransferWalletIds = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	wallets := map[uuid.UUID]*model.Wallet{a: {Id: a, Status: model.WalletActive}, c: {Id: c, Status: model.WalletActive}}
+	feeTransfer := model.TransferMoneyRequest{From: c, To: a, Fee: model.MustParseMoney("1"), FeeWallet: b}
+	if err := storage.CheckTransferWallets(feeTransfer, wallets); !errors.Is(err, domain.ErrWalletNotFound) {
+		t.Errorf("CheckTransferWallets without fee wallet: %v, want ErrWalletNotFound", err)
+	}
+	wallets[b] = &model.Wallet{Id: b, Status: model.WalletFrozen}
+	if err := storage.CheckTransferWallets(feeTransfer, wallets); !errors.Is(err, domain.ErrWalletFrozen) {
+		t.Errorf("CheckTransferWallets with frozen fee wallet: %v, want ErrWalletFrozen", err)
+	}
+}
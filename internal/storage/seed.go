@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/model"
+	"log/slog"
+)
+
+// SeedWallets наполняет пустое хранилище кошельками по настройкам cfg.
+// Если в хранилище уже есть кошельки или cfg.Wallets равен нулю, ничего не делает,
+// поэтому повторный запуск безопасен.
+// Параметры:
+//   - ctx: контекст выполнения.
+//   - wallets: хранилище кошельков.
+//   - cfg: количество кошельков и их начальный баланс.
+//
+// Возвращает:
+//   - int: количество созданных кошельков.
+//   - error: ошибку при разборе баланса или создании кошелька.
+func SeedWallets(ctx context.Context, wallets WalletStorage, cfg config.SeedConfig) (int, error) {
+	if cfg.Wallets <= 0 {
+		return 0, nil
+	}
+
+	balance, err := model.ParseMoney(cfg.Balance)
+	if err != nil {
+		return 0, fmt.Errorf("invalid seed balance: %w", err)
+	}
+	if balance.Sign() < 0 {
+		return 0, fmt.Errorf("invalid seed balance: %s is negative", balance)
+	}
+
+	count, err := wallets.CountWallets(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	for i := range cfg.Wallets {
+		wallet := model.Wallet{
+			Id:      uuid.New(),
+			Balance: balance,
+			Status:  model.WalletActive,
+		}
+		if err := wallets.CreateWallet(ctx, &wallet); err != nil {
+			return i, fmt.Errorf("failed to insert wallet: %w", err)
+		}
+		slog.InfoContext(ctx, "inserted default wallet", slog.String("wallet_id", wallet.Id.String()))
+	}
+	return cfg.Wallets, nil
+}
@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage"
+	"time"
+)
+
+// APIKeyRepository управляет ключами API в SQLite.
+type APIKeyRepository struct {
+	db           *DB
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+var _ storage.APIKeyStorage = (*APIKeyRepository)(nil)
+
+// NewAPIKeyRepository создаёт новый репозиторий ключей API.
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:           db,
+		readTimeout:  time.Duration(db.config.ReadTimeout),
+		writeTimeout: time.Duration(db.config.WriteTimeout),
+	}
+}
+
+// apiKeyColumns — список столбцов ключа в порядке, ожидаемом scanAPIKey.
+const apiKeyColumns = "id, name, key_hash, role, created_at, revoked_at"
+
+// scanAPIKey читает строку с apiKeyColumns в model.APIKey без списка кошельков.
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*model.APIKey, error) {
+	var key model.APIKey
+	var revokedAt sql.NullString
+	if err := row.Scan(&key.Id, &key.Name, &key.Hash, &key.Role, timeScanner{&key.CreatedAt}, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		t, err := parseTime(revokedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		key.RevokedAt = &t
+	}
+	key.Wallets = []uuid.UUID{}
+	return &key, nil
+}
+
+// CreateAPIKey сохраняет ключ и его привязку к кошелькам в одной транзакции.
+func (kr *APIKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (err error) {
+	ctx, done := storage.OperationContext(ctx, kr.writeTimeout)
+	defer done(&err)
+
+	ts := now()
+	err = kr.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, walletId := range key.Wallets {
+			if _, err := getWallet(ctx, tx, walletId); err != nil {
+				return err
+			}
+		}
+
+		insertQuery := "INSERT INTO api_keys (id, name, key_hash, role, created_at) VALUES (?, ?, ?, ?, ?)"
+		if _, err := tx.ExecContext(ctx, insertQuery, key.Id, key.Name, key.Hash, key.Role, ts); err != nil {
+			return err
+		}
+
+		for _, walletId := range key.Wallets {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO api_key_wallets (key_id, wallet_id) VALUES (?, ?)", key.Id, walletId); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	key.CreatedAt, _ = parseTime(ts)
+	return nil
+}
+
+// GetAPIKeyByHash возвращает ключ, в том числе отозванный, по SHA-256 хешу.
+func (kr *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (_ *model.APIKey, err error) {
+	ctx, done := storage.OperationContext(ctx, kr.readTimeout)
+	defer done(&err)
+
+	key, err := scanAPIKey(kr.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ?", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.Wallets, err = keyWallets(ctx, kr.db, key.Id); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys возвращает все ключи, от новых к старым.
+func (kr *APIKeyRepository) ListAPIKeys(ctx context.Context) (_ []model.APIKey, err error) {
+	ctx, done := storage.OperationContext(ctx, kr.readTimeout)
+	defer done(&err)
+
+	rows, err := kr.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	_ = rows.Close()
+
+	for i := range keys {
+		if keys[i].Wallets, err = keyWallets(ctx, kr.db, keys[i].Id); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв не меняет время первого.
+func (kr *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (_ *model.APIKey, err error) {
+	ctx, done := storage.OperationContext(ctx, kr.writeTimeout)
+	defer done(&err)
+
+	var key *model.APIKey
+	err = kr.db.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?", now(), id); err != nil {
+			return err
+		}
+
+		var err error
+		key, err = scanAPIKey(tx.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		key.Wallets, err = keyWallets(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// keyWallets возвращает кошельки, привязанные к ключу.
+func keyWallets(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, keyId uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.QueryContext(ctx, "SELECT wallet_id FROM api_key_wallets WHERE key_id = ? ORDER BY wallet_id", keyId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	wallets := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var walletId uuid.UUID
+		if err := rows.Scan(&walletId); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, walletId)
+	}
+	return wallets, rows.Err()
+}
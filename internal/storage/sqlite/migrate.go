@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+)
+
+// schema — текущая схема базы данных SQLite, по ней создаются новые базы.
+//
+//go:embed schema.sql
+var schema string
+
+// migrations — изменения схемы существующих баз по версиям: migrations[i] переводит базу
+// с версии i+1 на i+2. Версия 1 — схема до появления версий. Новая миграция добавляется
+// в конец вместе с тем же изменением в schema.sql.
+var migrations = []string{
+	// 2: комиссия перевода
+	`ALTER TABLE transactions ADD COLUMN fee TEXT NOT NULL DEFAULT '0'`,
+}
+
+// schemaVersion — версия schema.sql. Версия базы хранится в PRAGMA user_version.
+var schemaVersion = len(migrations) + 1
+
+// migrate создаёт схему в пустой базе или применяет к существующей недостающие миграции.
+// Каждая миграция выполняется в своей транзакции вместе с записью новой версии.
+// Наполнение кошельками выполняется отдельно, см. storage.SeedWallets.
+func migrate(db *sql.DB) error {
+	version, stored, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, schemaVersion)
+	}
+
+	if version == 0 {
+		return applyVersion(db, schemaVersion, schema)
+	}
+	if !stored {
+		if err := applyVersion(db, version, ""); err != nil {
+			return err
+		}
+	}
+	for v := version + 1; v <= schemaVersion; v++ {
+		if err := applyVersion(db, v, migrations[v-2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentVersion возвращает версию схемы базы (0 — пустая база) и записана ли она в user_version.
+// Базы, созданные до появления версий, имеют user_version 0, но уже содержат таблицы:
+// их версия определяется однократно по наличию transactions.fee и затем записывается в user_version.
+func currentVersion(db *sql.DB) (version int, stored bool, err error) {
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > 0 {
+		return version, true, nil
+	}
+
+	var tables, feeColumns int
+	query := `SELECT
+		(SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'wallets'),
+		(SELECT COUNT(*) FROM pragma_table_info('transactions') WHERE name = 'fee')`
+	if err := db.QueryRow(query).Scan(&tables, &feeColumns); err != nil {
+		return 0, false, fmt.Errorf("failed to detect schema version: %w", err)
+	}
+	switch {
+	case tables == 0:
+		return 0, false, nil
+	case feeColumns == 0:
+		return 1, false, nil
+	}
+	return 2, false, nil
+}
+
+// applyVersion выполняет script (если он задан) и записывает версию схемы version в одной транзакции.
+func applyVersion(db *sql.DB, version int, script string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback() // безопасно вызвать Rollback даже после Commit
+	}()
+
+	if script != "" {
+		if _, err := tx.Exec(script); err != nil {
+			return fmt.Errorf("failed to apply schema version %d: %w", version, err)
+		}
+	}
+	// PRAGMA не принимает параметры, version — число из кода
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return fmt.Errorf("failed to record schema version %d: %w", version, err)
+	}
+	return tx.Commit()
+}
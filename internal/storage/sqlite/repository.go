@@ -0,0 +1,616 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/domain"
+	"golang-server/internal/limits"
+	"golang-server/internal/model"
+	"golang-server/internal/storage"
+	"strings"
+	"time"
+)
+
+// TransactionRepository управляет транзакциями между кошельками в SQLite.
+type TransactionRepository struct {
+	db           *DB
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// WalletRepository управляет данными кошельков в SQLite.
+type WalletRepository struct {
+	db           *DB
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+var (
+	_ storage.WalletStorage      = (*WalletRepository)(nil)
+	_ storage.TransactionStorage = (*TransactionRepository)(nil)
+)
+
+// NewTransactionRepository создаёт новый репозиторий транзакций.
+func NewTransactionRepository(db *DB) *TransactionRepository {
+	return &TransactionRepository{
+		db:           db,
+		readTimeout:  time.Duration(db.config.ReadTimeout),
+		writeTimeout: time.Duration(db.config.WriteTimeout),
+	}
+}
+
+// NewWalletRepository создаёт новый репозиторий кошельков.
+func NewWalletRepository(db *DB) *WalletRepository {
+	return &WalletRepository{
+		db:           db,
+		readTimeout:  time.Duration(db.config.ReadTimeout),
+		writeTimeout: time.Duration(db.config.WriteTimeout),
+	}
+}
+
+// timeScanner читает время из текстового столбца в формате timeFormat.
+type timeScanner struct {
+	dst *time.Time
+}
+
+func (s timeScanner) Scan(src any) error {
+	var str string
+	switch v := src.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into time", src)
+	}
+	t, err := parseTime(str)
+	if err != nil {
+		return err
+	}
+	*s.dst = t
+	return nil
+}
+
+// inTx выполняет fn в транзакции (BEGIN IMMEDIATE) и фиксирует её, если fn не вернула ошибку.
+func (db *DB) inTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// walletColumns — список столбцов кошелька в порядке, ожидаемом scanWallet.
+const walletColumns = "id, balance, status, owner_ref, metadata, created_at, date_update"
+
+// scanWallet читает строку с walletColumns в model.Wallet.
+func scanWallet(row interface{ Scan(dest ...any) error }) (*model.Wallet, error) {
+	var wallet model.Wallet
+	var metadata string
+	err := row.Scan(
+		&wallet.Id,
+		&wallet.Balance,
+		&wallet.Status,
+		&wallet.OwnerRef,
+		&metadata,
+		timeScanner{&wallet.CreatedAt},
+		timeScanner{&wallet.DateUpdate},
+	)
+	if err != nil {
+		return nil, err
+	}
+	wallet.Metadata = json.RawMessage(metadata)
+	return &wallet, nil
+}
+
+// getWallet читает кошелёк по ID или возвращает domain.ErrWalletNotFound.
+func getWallet(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, walletId uuid.UUID) (*model.Wallet, error) {
+	wallet, err := scanWallet(q.QueryRowContext(ctx, "SELECT "+walletColumns+" FROM wallets WHERE id = ?", walletId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWalletNotFound, walletId)
+	}
+	return wallet, err
+}
+
+// getWallets читает кошельки ids одним запросом в порядке ID. Отсутствующие кошельки в результат не попадают.
+func getWallets(ctx context.Context, q storage.Querier, ids []uuid.UUID) (map[uuid.UUID]*model.Wallet, error) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	rows, err := q.QueryContext(ctx, "SELECT "+walletColumns+" FROM wallets WHERE id IN ("+placeholders+") ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	wallets := make(map[uuid.UUID]*model.Wallet, len(ids))
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		wallets[wallet.Id] = wallet
+	}
+	return wallets, rows.Err()
+}
+
+// CountWallets возвращает количество кошельков.
+func (wr *WalletRepository) CountWallets(ctx context.Context) (_ int, err error) {
+	ctx, done := storage.OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	var count int
+	if err := wr.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM wallets").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to query wallets count: %w", err)
+	}
+	return count, nil
+}
+
+// GetBalance возвращает текущий баланс кошелька по его ID.
+func (wr *WalletRepository) GetBalance(ctx context.Context, walletId uuid.UUID) (_ model.Money, err error) {
+	ctx, done := storage.OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	wallet, err := getWallet(ctx, wr.db, walletId)
+	if err != nil {
+		return model.Money{}, err
+	}
+	return wallet.Balance, nil
+}
+
+// GetWallet возвращает информацию о кошельке по его ID.
+func (wr *WalletRepository) GetWallet(ctx context.Context, walletId uuid.UUID) (_ *model.Wallet, err error) {
+	ctx, done := storage.OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	return getWallet(ctx, wr.db, walletId)
+}
+
+// CreateWallet создаёт кошелёк и проводку его начального баланса в одной транзакции.
+func (wr *WalletRepository) CreateWallet(ctx context.Context, wallet *model.Wallet) (err error) {
+	ctx, done := storage.OperationContext(ctx, wr.writeTimeout)
+	defer done(&err)
+
+	if len(wallet.Metadata) == 0 || string(wallet.Metadata) == "null" {
+		wallet.Metadata = json.RawMessage("{}")
+	}
+
+	ts := now()
+	err = wr.db.inTx(ctx, func(tx *sql.Tx) error {
+		insertQuery :=
+			`INSERT INTO wallets (id, balance, status, owner_ref, metadata, created_at, date_update)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.ExecContext(ctx, insertQuery, wallet.Id, wallet.Balance, wallet.Status, wallet.OwnerRef, string(wallet.Metadata), ts, ts)
+		if err != nil {
+			return err
+		}
+
+		openingQuery := "INSERT INTO ledger_entries (id, transaction_id, wallet_id, entry_type, amount, created_at) VALUES (?, NULL, ?, ?, ?, ?)"
+		_, err = tx.ExecContext(ctx, openingQuery, uuid.New(), wallet.Id, model.LedgerOpening, wallet.Balance, ts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	created, _ := parseTime(ts)
+	wallet.CreatedAt = created
+	wallet.DateUpdate = created
+	return nil
+}
+
+// UpdateWalletStatus меняет статус кошелька с проверкой допустимости перехода
+// и записывает изменение в историю в той же транзакции.
+func (wr *WalletRepository) UpdateWalletStatus(ctx context.Context, walletId uuid.UUID, change model.WalletStatusChange) (_ *model.Wallet, err error) {
+	ctx, done := storage.OperationContext(ctx, wr.writeTimeout)
+	defer done(&err)
+
+	var wallet *model.Wallet
+	err = wr.db.inTx(ctx, func(tx *sql.Tx) error {
+		current, err := getWallet(ctx, tx, walletId)
+		if err != nil {
+			return err
+		}
+
+		if !current.Status.CanTransitionTo(change.NewStatus) {
+			return fmt.Errorf("%w: %s -> %s", domain.ErrInvalidStatusTransition, current.Status, change.NewStatus)
+		}
+		if change.NewStatus == model.WalletClosed && !current.Balance.IsZero() {
+			return domain.ErrWalletNotEmpty
+		}
+
+		ts := now()
+		if _, err := tx.ExecContext(ctx, "UPDATE wallets SET status = ?, date_update = ? WHERE id = ?", change.NewStatus, ts, walletId); err != nil {
+			return err
+		}
+
+		historyQuery :=
+			`INSERT INTO wallet_status_history (id, wallet_id, old_status, new_status, reason, actor, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+		_, err = tx.ExecContext(ctx, historyQuery, uuid.New(), walletId, current.Status, change.NewStatus, change.Reason, change.Actor, ts)
+		if err != nil {
+			return err
+		}
+
+		wallet, err = getWallet(ctx, tx, walletId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// GetWalletStatusHistory возвращает историю смены статусов кошелька, от новых к старым.
+func (wr *WalletRepository) GetWalletStatusHistory(ctx context.Context, walletId uuid.UUID) (_ []model.WalletStatusChange, err error) {
+	ctx, done := storage.OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	query :=
+		`SELECT id, wallet_id, old_status, new_status, reason, actor, changed_at
+	FROM wallet_status_history
+	WHERE wallet_id = ?
+	ORDER BY changed_at DESC, id DESC`
+
+	rows, err := wr.db.QueryContext(ctx, query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	history := make([]model.WalletStatusChange, 0)
+	for rows.Next() {
+		var c model.WalletStatusChange
+		if err := rows.Scan(&c.Id, &c.WalletId, &c.OldStatus, &c.NewStatus, &c.Reason, &c.Actor, timeScanner{&c.ChangedAt}); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetLedgerBalance пересчитывает баланс кошелька по журналу проводок.
+// Суммирование выполняется в Go, так как SQLite не поддерживает точную десятичную арифметику.
+func (wr *WalletRepository) GetLedgerBalance(ctx context.Context, walletId uuid.UUID) (_ model.Money, err error) {
+	ctx, done := storage.OperationContext(ctx, wr.readTimeout)
+	defer done(&err)
+
+	return sumLedger(ctx, wr.db, "SELECT amount FROM ledger_entries WHERE wallet_id = ?", walletId)
+}
+
+// sumLedger суммирует денежный столбец, возвращаемый запросом.
+func sumLedger(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, query string, args ...any) (model.Money, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return model.Money{}, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var sum model.Money
+	for rows.Next() {
+		var amount model.Money
+		if err := rows.Scan(&amount); err != nil {
+			return model.Money{}, err
+		}
+		sum = sum.Add(amount)
+	}
+	return sum, rows.Err()
+}
+
+// SendMoney переводит деньги между кошельками в рамках одной транзакции BEGIN IMMEDIATE.
+// Проверки и возвращаемые ошибки совпадают с storage.TransactionRepository.SendMoney:
+// существование кошельков, их статус, лимиты отправителя, достаточность средств с учётом комиссии,
+// идемпотентность и инвариант двойной записи.
+func (tr *TransactionRepository) SendMoney(ctx context.Context, data model.TransferMoneyRequest) (_ *model.Transaction, err error) {
+	ctx, done := storage.OperationContext(ctx, tr.writeTimeout)
+	defer done(&err)
+
+	var transaction *model.Transaction
+
+	err = tr.db.inTx(ctx, func(sqlTx *sql.Tx) error {
+		// Каждый запрос перевода выполняется в своём span'е трассировки
+		tx := storage.TracedTx{Tx: sqlTx, System: "sqlite"}
+
+		if data.IdempotencyKey != "" {
+			var requestHash string
+			var existingId uuid.UUID
+			err := tx.QueryRowContext(ctx, "SELECT request_hash, transaction_id FROM idempotency_keys WHERE key = ?", data.IdempotencyKey).
+				Scan(&requestHash, &existingId)
+			switch {
+			case err == nil && requestHash != data.Fingerprint():
+				return domain.ErrIdempotencyConflict
+			case err == nil:
+				if transaction, err = getTransaction(ctx, tx, existingId); err != nil {
+					return err
+				}
+				transaction.Replayed = true
+				return nil
+			case !errors.Is(err, sql.ErrNoRows):
+				return err
+			}
+		}
+
+		// Кошельки читаются и обновляются в том же порядке ID, в котором их блокирует Postgres
+		ids := storage.TransferWalletIds(data)
+		wallets, err := getWallets(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+		if err := storage.CheckTransferWallets(data, wallets); err != nil {
+			return err
+		}
+		if err := checkLimits(ctx, tx, data); err != nil {
+			return err
+		}
+		from := wallets[data.From]
+		if from.Balance.Cmp(data.Amount.Add(data.Fee)) < 0 {
+			return domain.ErrInsufficientFunds
+		}
+
+		ts, err := nextTransferTime(ctx, tx)
+		if err != nil {
+			return err
+		}
+		// Балансы считаются по карте: кошелёк для комиссий может совпадать с получателем
+		from.Balance = from.Balance.Sub(data.Amount.Add(data.Fee))
+		wallets[data.To].Balance = wallets[data.To].Balance.Add(data.Amount)
+		if data.Fee.Sign() > 0 {
+			wallets[data.FeeWallet].Balance = wallets[data.FeeWallet].Balance.Add(data.Fee)
+		}
+		updateQuery := "UPDATE wallets SET balance = ?, date_update = ? WHERE id = ?"
+		for _, id := range ids {
+			if _, err := tx.ExecContext(ctx, updateQuery, wallets[id].Balance, ts, id); err != nil {
+				return err
+			}
+		}
+
+		transaction = &model.Transaction{Id: uuid.New(), From: data.From, To: data.To, Amount: data.Amount, Fee: data.Fee}
+		if transaction.TransferDate, err = parseTime(ts); err != nil {
+			return err
+		}
+		sendQuery := "INSERT INTO transactions (id, from_wallet, to_wallet, amount, fee, transfer_date) VALUES (?, ?, ?, ?, ?, ?)"
+		if _, err := tx.ExecContext(ctx, sendQuery, transaction.Id, data.From, data.To, data.Amount, data.Fee, ts); err != nil {
+			return err
+		}
+
+		if err := postTransfer(ctx, tx, transaction.Id, data, ts); err != nil {
+			return err
+		}
+
+		if data.IdempotencyKey == "" {
+			return nil
+		}
+		keyQuery := "INSERT INTO idempotency_keys (key, request_hash, transaction_id, created_at) VALUES (?, ?, ?, ?)"
+		_, err = tx.ExecContext(ctx, keyQuery, data.IdempotencyKey, data.Fingerprint(), transaction.Id, ts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// getTransaction читает перевод по ID.
+func getTransaction(ctx context.Context, tx storage.Querier, id uuid.UUID) (*model.Transaction, error) {
+	var t model.Transaction
+	query := "SELECT id, from_wallet, to_wallet, amount, fee, transfer_date FROM transactions WHERE id = ?"
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&t.Id, &t.From, &t.To, &t.Amount, &t.Fee, timeScanner{&t.TransferDate}); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// checkLimits проверяет перевод по лимитам отправителя data.Limits.
+// Суммы считаются в Go по переводам отправителя с начала самого раннего периода.
+func checkLimits(ctx context.Context, tx storage.Querier, data model.TransferMoneyRequest) error {
+	if !limits.NeedsUsage(data.Limits) {
+		return limits.CheckAmount(data.Limits, data.Amount)
+	}
+
+	w := limits.WindowsAt(time.Now())
+	rows, err := tx.QueryContext(ctx, "SELECT amount, transfer_date FROM transactions WHERE from_wallet = ? AND transfer_date >= ?",
+		data.From, formatTime(w.Since()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var usage model.OutgoingUsage
+	for rows.Next() {
+		var amount model.Money
+		var at time.Time
+		if err := rows.Scan(&amount, timeScanner{&at}); err != nil {
+			return err
+		}
+		w.Add(&usage, amount, at)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return limits.Check(data.Limits, data.Amount, usage)
+}
+
+// nextTransferTime возвращает время нового перевода, строго большее времени последнего.
+// Переводы выполняются последовательно (BEGIN IMMEDIATE), поэтому порядок истории
+// совпадает с порядком переводов даже при совпадении системного времени.
+func nextTransferTime(ctx context.Context, tx storage.Querier) (string, error) {
+	ts := time.Now().UTC().Truncate(time.Microsecond)
+
+	var last sql.NullString
+	if err := tx.QueryRowContext(ctx, "SELECT MAX(transfer_date) FROM transactions").Scan(&last); err != nil {
+		return "", err
+	}
+	if last.Valid {
+		lastTs, err := parseTime(last.String)
+		if err != nil {
+			return "", err
+		}
+		if !ts.After(lastTs) {
+			ts = lastTs.Add(time.Microsecond)
+		}
+	}
+	return formatTime(ts), nil
+}
+
+// postTransfer записывает проводки перевода (см. storage.TransferEntries) и проверяет, что их сумма равна нулю.
+func postTransfer(ctx context.Context, tx storage.Querier, transactionId uuid.UUID, data model.TransferMoneyRequest, ts string) error {
+	insertQuery := "INSERT INTO ledger_entries (id, transaction_id, wallet_id, entry_type, amount, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	for _, e := range storage.TransferEntries(transactionId, data) {
+		if _, err := tx.ExecContext(ctx, insertQuery, e.Id, e.TransactionId, e.WalletId, e.Type, e.Amount, ts); err != nil {
+			return err
+		}
+	}
+
+	sum, err := sumLedger(ctx, tx, "SELECT amount FROM ledger_entries WHERE transaction_id = ?", transactionId)
+	if err != nil {
+		return err
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("ledger invariant violated: postings of transaction %s sum to %s", transactionId, sum)
+	}
+	return nil
+}
+
+// GetLastTransactions возвращает последние N транзакций.
+func (tr *TransactionRepository) GetLastTransactions(ctx context.Context, numberOfTx int) (_ []model.Transaction, err error) {
+	ctx, done := storage.OperationContext(ctx, tr.readTimeout)
+	defer done(&err)
+
+	query :=
+		`SELECT id, from_wallet, to_wallet, amount, fee, transfer_date
+	FROM transactions
+	ORDER BY transfer_date DESC, id DESC
+	LIMIT ?`
+
+	rows, err := tr.db.QueryContext(ctx, query, numberOfTx)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows, numberOfTx, nil)
+}
+
+// GetWalletTransactions возвращает переводы кошелька по фильтру,
+// упорядоченные по (transfer_date, id) по убыванию.
+// Фильтр по сумме применяется в Go, так как суммы хранятся текстом;
+// в этом случае строки читаются без LIMIT до набора нужного количества.
+func (tr *TransactionRepository) GetWalletTransactions(ctx context.Context, filter model.TransactionFilter) (_ []model.Transaction, err error) {
+	ctx, done := storage.OperationContext(ctx, tr.readTimeout)
+	defer done(&err)
+
+	args := []any{}
+	conditions := make([]string, 0, 4)
+
+	switch filter.Direction {
+	case model.DirectionIncoming:
+		conditions = append(conditions, "to_wallet = ?")
+		args = append(args, filter.WalletId)
+	case model.DirectionOutgoing:
+		conditions = append(conditions, "from_wallet = ?")
+		args = append(args, filter.WalletId)
+	default:
+		conditions = append(conditions, "(from_wallet = ? OR to_wallet = ?)")
+		args = append(args, filter.WalletId, filter.WalletId)
+	}
+
+	if filter.FromDate != nil {
+		conditions = append(conditions, "transfer_date >= ?")
+		args = append(args, formatTime(*filter.FromDate))
+	}
+	if filter.ToDate != nil {
+		conditions = append(conditions, "transfer_date < ?")
+		args = append(args, formatTime(*filter.ToDate))
+	}
+	if filter.Cursor != nil {
+		conditions = append(conditions, "(transfer_date, id) < (?, ?)")
+		args = append(args, formatTime(filter.Cursor.TransferDate), filter.Cursor.Id)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, from_wallet, to_wallet, amount, fee, transfer_date
+	FROM transactions
+	WHERE %s
+	ORDER BY transfer_date DESC, id DESC`, strings.Join(conditions, " AND "))
+
+	amountFilter := filter.MinAmount != nil || filter.MaxAmount != nil
+	if !amountFilter {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := tr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTransactions(rows, filter.Limit, func(t model.Transaction) bool {
+		if filter.MinAmount != nil && t.Amount.Cmp(*filter.MinAmount) < 0 {
+			return false
+		}
+		if filter.MaxAmount != nil && t.Amount.Cmp(*filter.MaxAmount) > 0 {
+			return false
+		}
+		return true
+	})
+}
+
+// scanTransactions читает не более limit транзакций, удовлетворяющих keep (nil — все), и закрывает rows.
+func scanTransactions(rows *sql.Rows, limit int, keep func(model.Transaction) bool) ([]model.Transaction, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	transactions := make([]model.Transaction, 0, limit)
+	for len(transactions) < limit && rows.Next() {
+		var t model.Transaction
+		if err := rows.Scan(&t.Id, &t.From, &t.To, &t.Amount, &t.Fee, timeScanner{&t.TransferDate}); err != nil {
+			return nil, err
+		}
+		if keep == nil || keep(t) {
+			transactions = append(transactions, t)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,268 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/config"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage/storagetest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// testRepositories открывает пустую базу во временном каталоге теста.
+func testRepositories(t *testing.T) (*WalletRepository, *TransactionRepository) {
+	t.Helper()
+
+	db, err := Open(config.DbConfig{Path: filepath.Join(t.TempDir(), "wallet.db")})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return NewWalletRepository(db), NewTransactionRepository(db)
+}
+
+func TestStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Stores {
+		wr, tr := testRepositories(t)
+		return storagetest.Stores{Wallets: wr, Transactions: tr}
+	})
+}
+
+// schemaV1 — схема до появления столбца transactions.fee.
+//
+//go:embed testdata/schema_v1.sql
+var schemaV1 string
+
+func TestOpenUpgradesSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.db")
+	old, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := old.Exec(schemaV1); err != nil {
+		t.Fatalf("old schema: %v", err)
+	}
+	a, b := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{a, b} {
+		if _, err := old.Exec(`INSERT INTO wallets (id, balance, created_at, date_update) VALUES (?, '100', ?, ?)`, id.String(), now(), now()); err != nil {
+			t.Fatalf("insert wallet: %v", err)
+		}
+		if _, err := old.Exec(`INSERT INTO ledger_entries (id, wallet_id, entry_type, amount, created_at) VALUES (?, ?, 'opening', '100', ?)`, uuid.NewString(), id.String(), now()); err != nil {
+			t.Fatalf("insert opening entry: %v", err)
+		}
+	}
+	if _, err := old.Exec(`INSERT INTO transactions (id, from_wallet, to_wallet, amount, transfer_date) VALUES (?, ?, ?, '5', ?)`, uuid.NewString(), a.String(), b.String(), now()); err != nil {
+		t.Fatalf("insert transaction: %v", err)
+	}
+	_ = old.Close()
+
+	upgraded, err := Open(config.DbConfig{Path: path})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	assertSchemaVersion(t, upgraded.DB, schemaVersion)
+	_ = upgraded.Close()
+
+	// Повторное открытие не должно пытаться добавить столбец ещё раз
+	db, err := Open(config.DbConfig{Path: path})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	wr, tr := NewWalletRepository(db), NewTransactionRepository(db)
+
+	fee := model.MustParseMoney("0.5")
+	sent, err := tr.SendMoney(t.Context(), model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("10"), Fee: fee, FeeWallet: b})
+	if err != nil {
+		t.Fatalf("SendMoney: %v", err)
+	}
+	txs, err := tr.GetWalletTransactions(t.Context(), model.TransactionFilter{WalletId: a, Limit: 10})
+	if err != nil || len(txs) != 2 {
+		t.Fatalf("GetWalletTransactions = %+v, %v; want 2 transactions", txs, err)
+	}
+	if txs[0].Id != sent.Id || txs[0].Fee.Cmp(fee) != 0 || !txs[1].Fee.IsZero() {
+		t.Errorf("history = %+v; want the new transfer with fee %s and the old one without a fee", txs, fee)
+	}
+	storagetest.AssertBalance(t, wr, a, "89.5")
+	storagetest.AssertBalance(t, wr, b, "110.5")
+}
+
+func TestOpenSchemaVersion(t *testing.T) {
+	// Новая база получает текущую версию схемы
+	wr, _ := testRepositories(t)
+	assertSchemaVersion(t, wr.db.DB, schemaVersion)
+
+	// База с текущей схемой, созданная до появления версий, не мигрируется повторно
+	path := filepath.Join(t.TempDir(), "unversioned.db")
+	unversioned, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	if _, err := unversioned.Exec(schema); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	_ = unversioned.Close()
+	db, err := Open(config.DbConfig{Path: path})
+	if err != nil {
+		t.Fatalf("Open unversioned database: %v", err)
+	}
+	assertSchemaVersion(t, db.DB, schemaVersion)
+
+	// Базу более новой версии открыть нельзя
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion+1)); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+	if db, err := Open(config.DbConfig{Path: path}); err == nil {
+		_ = db.Close()
+		t.Error("Open of a newer schema version succeeded, want error")
+	}
+}
+
+// assertSchemaVersion проверяет версию схемы в PRAGMA user_version.
+func assertSchemaVersion(t *testing.T, db *sql.DB, want int) {
+	t.Helper()
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != want {
+		t.Errorf("user_version = %d, %v; want %d", version, err, want)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	wr, tr := testRepositories(t)
+	a := storagetest.NewWallet(t, wr, "100")
+	b := storagetest.NewWallet(t, wr, "0")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := tr.SendMoney(ctx, model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("1")}); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendMoney error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := wr.GetWallet(ctx, a); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetWallet error = %v, want %v", err, context.Canceled)
+	}
+	storagetest.AssertBalance(t, wr, a, "100")
+}
+
+func TestAPIKeys(t *testing.T) {
+	wr, _ := testRepositories(t)
+	kr := NewAPIKeyRepository(wr.db)
+	a := storagetest.NewWallet(t, wr, "10")
+	b := storagetest.NewWallet(t, wr, "10")
+
+	if err := kr.CreateAPIKey(t.Context(), &model.APIKey{Id: uuid.New(), Hash: "h0", Role: model.APIKeyClient, Wallets: []uuid.UUID{uuid.New()}}); !errors.Is(err, domain.ErrWalletNotFound) {
+		t.Fatalf("CreateAPIKey with an unknown wallet: %v, want ErrWalletNotFound", err)
+	}
+
+	client := model.APIKey{Id: uuid.New(), Name: "shop", Hash: "h1", Role: model.APIKeyClient, Wallets: []uuid.UUID{a, b}}
+	admin := model.APIKey{Id: uuid.New(), Name: "ops", Hash: "h2", Role: model.APIKeyAdmin}
+	for _, key := range []*model.APIKey{&client, &admin} {
+		if err := kr.CreateAPIKey(t.Context(), key); err != nil {
+			t.Fatalf("CreateAPIKey: %v", err)
+		}
+	}
+
+	got, err := kr.GetAPIKeyByHash(t.Context(), "h1")
+	if err != nil || got.Id != client.Id || got.Role != model.APIKeyClient || len(got.Wallets) != 2 || got.RevokedAt != nil {
+		t.Fatalf("GetAPIKeyByHash = %+v, %v", got, err)
+	}
+	if _, err := kr.GetAPIKeyByHash(t.Context(), "missing"); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("GetAPIKeyByHash(missing): %v, want ErrAPIKeyNotFound", err)
+	}
+
+	revoked, err := kr.RevokeAPIKey(t.Context(), client.Id)
+	if err != nil || revoked.RevokedAt == nil || len(revoked.Wallets) != 2 {
+		t.Fatalf("RevokeAPIKey = %+v, %v", revoked, err)
+	}
+	again, err := kr.RevokeAPIKey(t.Context(), client.Id)
+	if err != nil || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("repeated RevokeAPIKey changed revoked_at: %v, %v", again.RevokedAt, err)
+	}
+	if _, err := kr.RevokeAPIKey(t.Context(), uuid.New()); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey(unknown): %v, want ErrAPIKeyNotFound", err)
+	}
+
+	keys, err := kr.ListAPIKeys(t.Context())
+	if err != nil || len(keys) != 2 || keys[0].Id != admin.Id || keys[1].RevokedAt == nil {
+		t.Errorf("ListAPIKeys = %+v, %v", keys, err)
+	}
+}
+
+// postgresColumns собирает таблицы и столбцы из миграций PostgreSQL:
+// CREATE TABLE и ALTER TABLE ... ADD COLUMN.
+func postgresColumns(t *testing.T) map[string][]string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("..", "postgres", "migrations", "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("PostgreSQL migrations not found: %v", err)
+	}
+	createTable := regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS (\w+) \((.*?)\n\);`)
+	column := regexp.MustCompile(`(?m)^\s+(\w+) [A-Z]`)
+	addColumn := regexp.MustCompile(`ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+)`)
+
+	tables := make(map[string][]string)
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range createTable.FindAllStringSubmatch(string(script), -1) {
+			for _, c := range column.FindAllStringSubmatch(m[2], -1) {
+				if c[1] != "CHECK" && c[1] != "PRIMARY" {
+					tables[m[1]] = append(tables[m[1]], c[1])
+				}
+			}
+		}
+		for _, m := range addColumn.FindAllStringSubmatch(string(script), -1) {
+			tables[m[1]] = append(tables[m[1]], m[2])
+		}
+	}
+	return tables
+}
+
+func TestSchemaMatchesPostgres(t *testing.T) {
+	wr, _ := testRepositories(t)
+
+	want := postgresColumns(t)
+	rows, err := wr.db.Query(`SELECT m.name, p.name FROM sqlite_master m, pragma_table_info(m.name) p WHERE m.type = 'table'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			t.Fatal(err)
+		}
+		got[table] = append(got[table], column)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for table, columns := range want {
+		slices.Sort(columns)
+		slices.Sort(got[table])
+		if !slices.Equal(got[table], columns) {
+			t.Errorf("table %s: SQLite columns %v, PostgreSQL columns %v", table, got[table], columns)
+		}
+	}
+	for table := range got {
+		if _, ok := want[table]; !ok {
+			t.Errorf("table %s exists only in SQLite", table)
+		}
+	}
+}
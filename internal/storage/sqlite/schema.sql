@@ -0,0 +1,74 @@
+-- Схема SQLite повторяет итог миграций PostgreSQL из internal/storage/postgres/migrations:
+-- те же таблицы и столбцы, включая статусы кошельков и комиссии переводов (проверяет TestSchemaMatchesPostgres).
+-- Отличия: знак сумм в ledger_entries проверяется в Go, а не CHECK, и есть индекс по transfer_date
+-- для GetLastTransactions. Изменение схемы добавляется и в migrations (см. migrate.go).
+-- Денежные суммы хранятся текстом в каноническом виде model.Money, арифметика выполняется в Go.
+-- Время хранится текстом в UTC фиксированной ширины, поэтому сортируется лексикографически.
+CREATE TABLE IF NOT EXISTS wallets (
+    id TEXT PRIMARY KEY,
+    balance TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen', 'closed')),
+    owner_ref TEXT,
+    metadata TEXT NOT NULL DEFAULT '{}',
+    created_at TEXT NOT NULL,
+    date_update TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS wallet_status_history (
+    id TEXT PRIMARY KEY,
+    wallet_id TEXT NOT NULL REFERENCES wallets(id),
+    old_status TEXT NOT NULL,
+    new_status TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    changed_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_status_history_wallet_id_idx ON wallet_status_history (wallet_id, changed_at);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id TEXT PRIMARY KEY,
+    from_wallet TEXT REFERENCES wallets(id),
+    to_wallet TEXT REFERENCES wallets(id),
+    amount TEXT NOT NULL,
+    fee TEXT NOT NULL DEFAULT '0',
+    transfer_date TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS transactions_from_wallet_idx ON transactions (from_wallet, transfer_date DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_to_wallet_idx ON transactions (to_wallet, transfer_date DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transactions_transfer_date_idx ON transactions (transfer_date DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    transaction_id TEXT NOT NULL REFERENCES transactions(id),
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id TEXT PRIMARY KEY,
+    transaction_id TEXT REFERENCES transactions(id),
+    wallet_id TEXT NOT NULL REFERENCES wallets(id),
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('debit', 'credit', 'opening')),
+    amount TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_wallet_id_idx ON ledger_entries (wallet_id);
+CREATE INDEX IF NOT EXISTS ledger_entries_transaction_id_idx ON ledger_entries (transaction_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    role TEXT NOT NULL CHECK (role IN ('client', 'admin')),
+    created_at TEXT NOT NULL,
+    revoked_at TEXT
+);
+
+CREATE TABLE IF NOT EXISTS api_key_wallets (
+    key_id TEXT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    wallet_id TEXT NOT NULL REFERENCES wallets(id),
+    PRIMARY KEY (key_id, wallet_id)
+);
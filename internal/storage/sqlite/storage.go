@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-server/internal/config"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// timeFormat — формат хранения времени: UTC с микросекундами фиксированной ширины,
+// чтобы лексикографический порядок совпадал с хронологическим.
+const timeFormat = "2006-01-02T15:04:05.000000Z"
+
+// DB представляет собой обертку над *sql.DB для SQLite.
+type DB struct {
+	*sql.DB
+	config config.DbConfig
+}
+
+// Open открывает файл базы данных SQLite и создаёт или обновляет схему (см. migrate).
+// Используется чистый Go-драйвер modernc.org/sqlite, поэтому cgo не требуется.
+// Все транзакции начинаются как BEGIN IMMEDIATE: запись блокируется сразу,
+// и переводы выполняются последовательно, что даёт ту же атомарность, что и Serializable в PostgreSQL.
+// Параметры:
+//   - cfg: настройки базы данных с путём к файлу.
+//
+// Возвращает:
+//   - *DB: открытая база данных.
+//   - error: ошибку при открытии или обновлении схемы.
+func Open(cfg config.DbConfig) (*DB, error) {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Set("_txlock", "immediate")
+	dsn := "file:" + cfg.Path + "?" + q.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("schema migration error: %w", err)
+	}
+
+	return &DB{DB: db, config: cfg}, nil
+}
+
+// formatTime приводит время к формату хранения.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// parseTime разбирает время из формата хранения.
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(timeFormat, s)
+}
+
+// now возвращает текущее время в формате хранения.
+func now() string {
+	return formatTime(time.Now())
+}
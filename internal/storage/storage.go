@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"golang-server/internal/model"
+)
+
+// WalletStorage — хранилище кошельков.
+// Реализации: WalletRepository (PostgreSQL), sqlite.WalletRepository и memory.Store.
+// Отмена или истечение срока ctx прерывает операцию, а ошибка в этом случае
+// оборачивает context.Canceled или context.DeadlineExceeded; то же верно для TransactionStorage.
+type WalletStorage interface {
+	// GetBalance возвращает текущий баланс кошелька.
+	GetBalance(ctx context.Context, walletId uuid.UUID) (model.Money, error)
+	// GetWallet возвращает кошелёк или domain.ErrWalletNotFound.
+	GetWallet(ctx context.Context, walletId uuid.UUID) (*model.Wallet, error)
+	// CreateWallet создаёт кошелёк вместе с проводкой начального баланса.
+	CreateWallet(ctx context.Context, wallet *model.Wallet) error
+	// UpdateWalletStatus меняет статус кошелька и записывает изменение в историю.
+	UpdateWalletStatus(ctx context.Context, walletId uuid.UUID, change model.WalletStatusChange) (*model.Wallet, error)
+	// GetWalletStatusHistory возвращает историю смены статусов, от новых к старым.
+	GetWalletStatusHistory(ctx context.Context, walletId uuid.UUID) ([]model.WalletStatusChange, error)
+	// GetLedgerBalance пересчитывает баланс кошелька по журналу проводок.
+	GetLedgerBalance(ctx context.Context, walletId uuid.UUID) (model.Money, error)
+	// CountWallets возвращает количество кошельков в хранилище.
+	CountWallets(ctx context.Context) (int, error)
+}
+
+// TransactionStorage — хранилище переводов.
+// Реализации: TransactionRepository (PostgreSQL), sqlite.TransactionRepository и memory.Store.
+type TransactionStorage interface {
+	// SendMoney атомарно переводит средства вместе с комиссией и возвращает транзакцию.
+	// Повтор с тем же ключом идемпотентности возвращает исходную транзакцию с признаком Replayed.
+	SendMoney(ctx context.Context, data model.TransferMoneyRequest) (*model.Transaction, error)
+	// GetLastTransactions возвращает последние N транзакций, от новых к старым.
+	GetLastTransactions(ctx context.Context, numberOfTx int) ([]model.Transaction, error)
+	// GetWalletTransactions возвращает переводы кошелька по фильтру, от новых к старым.
+	GetWalletTransactions(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, error)
+}
+
+// APIKeyStorage — хранилище ключей API.
+// Реализации: APIKeyRepository (PostgreSQL), sqlite.APIKeyRepository и memory.Store.
+type APIKeyStorage interface {
+	// CreateAPIKey сохраняет ключ вместе с привязкой к кошелькам;
+	// domain.ErrWalletNotFound, если одного из кошельков не существует.
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	// GetAPIKeyByHash возвращает ключ, в том числе отозванный, по хешу или domain.ErrAPIKeyNotFound.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	// ListAPIKeys возвращает все ключи, от новых к старым.
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	// RevokeAPIKey отзывает ключ или возвращает domain.ErrAPIKeyNotFound.
+	// Повторный отзыв не меняет время первого.
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+}
+
+var (
+	_ WalletStorage      = (*WalletRepository)(nil)
+	_ TransactionStorage = (*TransactionRepository)(nil)
+	_ APIKeyStorage      = (*APIKeyRepository)(nil)
+)
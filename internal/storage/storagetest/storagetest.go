@@ -0,0 +1,324 @@
+// Package storagetest содержит общий набор тестов, который проходят все реализации
+// storage.WalletStorage и storage.TransactionStorage: PostgreSQL, SQLite и хранилище в памяти.
+package storagetest
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"golang-server/internal/domain"
+	"golang-server/internal/model"
+	"golang-server/internal/storage"
+	"sync"
+	"testing"
+)
+
+// Stores — хранилища кошельков и переводов одной базы.
+type Stores struct {
+	Wallets      storage.WalletStorage
+	Transactions storage.TransactionStorage
+}
+
+// Factory открывает хранилища для теста t. Базу могут разделять несколько тестов,
+// поэтому каждый тест работает только со своими кошельками и ключами идемпотентности.
+type Factory func(t *testing.T) Stores
+
+// Run выполняет набор тестов на хранилищах из newStores; каждый тест получает свои хранилища.
+func Run(t *testing.T, newStores Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s Stores)
+	}{
+		{"SendMoney", testSendMoney},
+		{"SendMoneyErrors", testSendMoneyErrors},
+		{"SendMoneyIdempotency", testSendMoneyIdempotency},
+		{"SendMoneyLimits", testSendMoneyLimits},
+		{"SendMoneyFee", testSendMoneyFee},
+		{"SendMoneyConcurrent", testSendMoneyConcurrent},
+		{"GetWalletTransactions", testGetWalletTransactions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStores(t))
+		})
+	}
+}
+
+// NewWallet создаёт активный кошелёк с начальным балансом.
+func NewWallet(t *testing.T, wallets storage.WalletStorage, balance string) uuid.UUID {
+	t.Helper()
+
+	wallet := model.Wallet{Id: uuid.New(), Balance: model.MustParseMoney(balance), Status: model.WalletActive}
+	if err := wallets.CreateWallet(t.Context(), &wallet); err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	return wallet.Id
+}
+
+// AssertBalance проверяет баланс кошелька и его совпадение с журналом проводок.
+func AssertBalance(t *testing.T, wallets storage.WalletStorage, id uuid.UUID, want string) {
+	t.Helper()
+
+	balance, err := wallets.GetBalance(t.Context(), id)
+	if err != nil {
+		t.Fatalf("GetBalance(%s): %v", id, err)
+	}
+	if balance.Cmp(model.MustParseMoney(want)) != 0 {
+		t.Errorf("balance of %s = %s, want %s", id, balance, want)
+	}
+
+	ledger, err := wallets.GetLedgerBalance(t.Context(), id)
+	if err != nil {
+		t.Fatalf("GetLedgerBalance(%s): %v", id, err)
+	}
+	if ledger.Cmp(balance) != 0 {
+		t.Errorf("ledger balance of %s = %s, wallet balance = %s", id, ledger, balance)
+	}
+}
+
+// history возвращает все переводы кошелька, от новых к старым.
+func history(t *testing.T, s Stores, walletId uuid.UUID) []model.Transaction {
+	t.Helper()
+
+	txs, err := s.Transactions.GetWalletTransactions(t.Context(), model.TransactionFilter{WalletId: walletId, Direction: model.DirectionAll, Limit: 100})
+	if err != nil {
+		t.Fatalf("GetWalletTransactions(%s): %v", walletId, err)
+	}
+	return txs
+}
+
+func testSendMoney(t *testing.T, s Stores) {
+	from := NewWallet(t, s.Wallets, "100")
+	to := NewWallet(t, s.Wallets, "0")
+
+	sent, err := s.Transactions.SendMoney(t.Context(), model.TransferMoneyRequest{From: from, To: to, Amount: model.MustParseMoney("30.25")})
+	if err != nil {
+		t.Fatalf("SendMoney: %v", err)
+	}
+	if _, err := s.Transactions.SendMoney(t.Context(), model.TransferMoneyRequest{From: to, To: from, Amount: model.MustParseMoney("0.25")}); err != nil {
+		t.Fatalf("SendMoney back: %v", err)
+	}
+
+	txs := history(t, s, from)
+	if len(txs) != 2 || txs[0].From != to || txs[1].Id != sent.Id || txs[1].From != from || txs[1].To != to {
+		t.Fatalf("history = %+v, want newest first with %s -> %s last", txs, from, to)
+	}
+	if txs[1].Amount.Cmp(model.MustParseMoney("30.25")) != 0 || !txs[1].TransferDate.Equal(sent.TransferDate) {
+		t.Errorf("stored transaction = %+v, want %+v", txs[1], sent)
+	}
+
+	AssertBalance(t, s.Wallets, from, "70")
+	AssertBalance(t, s.Wallets, to, "30")
+}
+
+func testSendMoneyErrors(t *testing.T, s Stores) {
+	a := NewWallet(t, s.Wallets, "100")
+	b := NewWallet(t, s.Wallets, "0")
+	frozen := NewWallet(t, s.Wallets, "0")
+	if _, err := s.Wallets.UpdateWalletStatus(t.Context(), frozen, model.WalletStatusChange{NewStatus: model.WalletFrozen, Actor: "test"}); err != nil {
+		t.Fatalf("UpdateWalletStatus: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  model.TransferMoneyRequest
+		want error
+	}{
+		{"insufficient funds", model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("500")}, domain.ErrInsufficientFunds},
+		{"amount beyond any balance", model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("123456789012345678901.12345678")}, domain.ErrInsufficientFunds},
+		{"unknown sender", model.TransferMoneyRequest{From: uuid.New(), To: a, Amount: model.MustParseMoney("1")}, domain.ErrWalletNotFound},
+		{"unknown recipient", model.TransferMoneyRequest{From: a, To: uuid.New(), Amount: model.MustParseMoney("1")}, domain.ErrWalletNotFound},
+		{"frozen sender", model.TransferMoneyRequest{From: frozen, To: a, Amount: model.MustParseMoney("1")}, domain.ErrWalletFrozen},
+		{"frozen recipient", model.TransferMoneyRequest{From: a, To: frozen, Amount: model.MustParseMoney("1")}, domain.ErrWalletFrozen},
+	}
+	for _, tt := range tests {
+		if _, err := s.Transactions.SendMoney(t.Context(), tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: SendMoney error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	changes, err := s.Wallets.GetWalletStatusHistory(t.Context(), frozen)
+	if err != nil || len(changes) != 1 || changes[0].NewStatus != model.WalletFrozen {
+		t.Errorf("GetWalletStatusHistory = %+v, %v; want one freeze record", changes, err)
+	}
+	AssertBalance(t, s.Wallets, a, "100")
+	AssertBalance(t, s.Wallets, b, "0")
+}
+
+func testSendMoneyIdempotency(t *testing.T, s Stores) {
+	a := NewWallet(t, s.Wallets, "100")
+	b := NewWallet(t, s.Wallets, "0")
+
+	req := model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("10"), IdempotencyKey: uuid.NewString()}
+	first, err := s.Transactions.SendMoney(t.Context(), req)
+	if err != nil {
+		t.Fatalf("SendMoney: %v", err)
+	}
+	replay, err := s.Transactions.SendMoney(t.Context(), req)
+	if err != nil {
+		t.Fatalf("SendMoney replay: %v", err)
+	}
+	if first.Id != replay.Id || !replay.Replayed || first.Replayed {
+		t.Errorf("replay returned transaction %s (replayed %t), want replayed %s", replay.Id, replay.Replayed, first.Id)
+	}
+
+	req.Amount = model.MustParseMoney("20")
+	if _, err := s.Transactions.SendMoney(t.Context(), req); !errors.Is(err, domain.ErrIdempotencyConflict) {
+		t.Errorf("SendMoney with changed body error = %v, want %v", err, domain.ErrIdempotencyConflict)
+	}
+
+	AssertBalance(t, s.Wallets, a, "90")
+	AssertBalance(t, s.Wallets, b, "10")
+}
+
+func testSendMoneyLimits(t *testing.T, s Stores) {
+	a := NewWallet(t, s.Wallets, "100")
+	b := NewWallet(t, s.Wallets, "0")
+
+	daily, maxTransfer, hourly := model.MustParseMoney("100"), model.MustParseMoney("0.5"), 2
+	steps := []struct {
+		amount string
+		limits *model.TransferLimits
+		want   error
+	}{
+		{"60", &model.TransferLimits{DailyOutgoing: &daily}, nil},
+		{"50", &model.TransferLimits{DailyOutgoing: &daily}, domain.ErrDailyLimitExceeded},
+		{"40", &model.TransferLimits{DailyOutgoing: &daily}, nil},
+		{"1", &model.TransferLimits{HourlyCount: &hourly}, domain.ErrHourlyCountExceeded},
+		{"1", &model.TransferLimits{MaxTransfer: &maxTransfer}, domain.ErrTransferLimitExceeded},
+	}
+	for i, step := range steps {
+		req := model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney(step.amount), Limits: step.limits}
+		if _, err := s.Transactions.SendMoney(t.Context(), req); !errors.Is(err, step.want) || (step.want == nil) != (err == nil) {
+			t.Errorf("step %d: SendMoney error = %v, want %v", i, err, step.want)
+		}
+	}
+
+	AssertBalance(t, s.Wallets, a, "0")
+	AssertBalance(t, s.Wallets, b, "100")
+}
+
+func testSendMoneyFee(t *testing.T, s Stores) {
+	a := NewWallet(t, s.Wallets, "100")
+	b := NewWallet(t, s.Wallets, "0")
+	fees := NewWallet(t, s.Wallets, "0")
+
+	fee := model.MustParseMoney("0.5")
+	req := model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("10"), Fee: fee, FeeWallet: fees, IdempotencyKey: uuid.NewString()}
+	sent, err := s.Transactions.SendMoney(t.Context(), req)
+	if err != nil {
+		t.Fatalf("SendMoney: %v", err)
+	}
+	if sent.Fee.Cmp(fee) != 0 {
+		t.Errorf("transaction fee = %s, want %s", sent.Fee, fee)
+	}
+	replay, err := s.Transactions.SendMoney(t.Context(), req)
+	if err != nil || replay.Id != sent.Id || replay.Fee.Cmp(fee) != 0 || !replay.Replayed || sent.Replayed {
+		t.Errorf("replay = %+v, %v; want replayed transaction %s with fee %s", replay, err, sent.Id, fee)
+	}
+
+	// Кошелёк для комиссий может быть и получателем
+	if _, err := s.Transactions.SendMoney(t.Context(), model.TransferMoneyRequest{From: a, To: fees, Amount: model.MustParseMoney("1"), Fee: fee, FeeWallet: fees}); err != nil {
+		t.Fatalf("SendMoney to the fee wallet: %v", err)
+	}
+
+	failures := []struct {
+		name string
+		req  model.TransferMoneyRequest
+		want error
+	}{
+		{"fee exceeds the rest", model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("88"), Fee: fee, FeeWallet: fees}, domain.ErrInsufficientFunds},
+		{"unknown fee wallet", model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("1"), Fee: fee, FeeWallet: uuid.New()}, domain.ErrWalletNotFound},
+	}
+	for _, tt := range failures {
+		if _, err := s.Transactions.SendMoney(t.Context(), tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: SendMoney error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	txs := history(t, s, b)
+	if len(txs) != 1 || txs[0].Id != sent.Id || txs[0].Fee.Cmp(fee) != 0 {
+		t.Errorf("history = %+v; want transaction %s with fee %s", txs, sent.Id, fee)
+	}
+
+	AssertBalance(t, s.Wallets, a, "88")
+	AssertBalance(t, s.Wallets, b, "10")
+	AssertBalance(t, s.Wallets, fees, "2")
+}
+
+// testSendMoneyConcurrent выполняет встречные переводы с комиссией: часть может упасть
+// из-за нехватки средств, но общий баланс должен сохраниться, а балансы — совпасть с журналом.
+// Встречные переводы блокируют одни и те же кошельки и не должны взаимоблокироваться.
+func testSendMoneyConcurrent(t *testing.T, s Stores) {
+	a := NewWallet(t, s.Wallets, "10")
+	b := NewWallet(t, s.Wallets, "10")
+	fees := NewWallet(t, s.Wallets, "0")
+
+	var wg sync.WaitGroup
+	for i := range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney("1"), Fee: model.MustParseMoney("0.01"), FeeWallet: fees}
+			if i%2 == 1 {
+				req.From, req.To = b, a
+			}
+			if _, err := s.Transactions.SendMoney(t.Context(), req); err != nil && !errors.Is(err, domain.ErrInsufficientFunds) {
+				t.Errorf("SendMoney: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total model.Money
+	for _, id := range []uuid.UUID{a, b, fees} {
+		balance, err := s.Wallets.GetBalance(t.Context(), id)
+		if err != nil {
+			t.Fatalf("GetBalance: %v", err)
+		}
+		AssertBalance(t, s.Wallets, id, balance.String())
+		total = total.Add(balance)
+	}
+	if total.Cmp(model.MustParseMoney("20")) != 0 {
+		t.Errorf("total balance = %s, want 20", total)
+	}
+}
+
+func testGetWalletTransactions(t *testing.T, s Stores) {
+	a := NewWallet(t, s.Wallets, "100")
+	b := NewWallet(t, s.Wallets, "100")
+
+	for _, amount := range []string{"1", "5", "10", "50"} {
+		if _, err := s.Transactions.SendMoney(t.Context(), model.TransferMoneyRequest{From: a, To: b, Amount: model.MustParseMoney(amount)}); err != nil {
+			t.Fatalf("SendMoney: %v", err)
+		}
+	}
+	if _, err := s.Transactions.SendMoney(t.Context(), model.TransferMoneyRequest{From: b, To: a, Amount: model.MustParseMoney("7")}); err != nil {
+		t.Fatalf("SendMoney: %v", err)
+	}
+
+	minAmount, maxAmount := model.MustParseMoney("5"), model.MustParseMoney("10")
+	filter := model.TransactionFilter{WalletId: b, Direction: model.DirectionIncoming, MinAmount: &minAmount, MaxAmount: &maxAmount, Limit: 1}
+	page, err := s.Transactions.GetWalletTransactions(t.Context(), filter)
+	if err != nil {
+		t.Fatalf("GetWalletTransactions: %v", err)
+	}
+	if len(page) != 1 || page[0].Amount.Cmp(maxAmount) != 0 {
+		t.Fatalf("first page = %+v, want the 10 transfer", page)
+	}
+
+	filter.Cursor = &model.TransactionCursor{TransferDate: page[0].TransferDate, Id: page[0].Id}
+	page, err = s.Transactions.GetWalletTransactions(t.Context(), filter)
+	if err != nil {
+		t.Fatalf("GetWalletTransactions: %v", err)
+	}
+	if len(page) != 1 || page[0].Amount.Cmp(minAmount) != 0 {
+		t.Fatalf("second page = %+v, want the 5 transfer", page)
+	}
+
+	filter.Direction = model.DirectionOutgoing
+	filter.Cursor = nil
+	page, err = s.Transactions.GetWalletTransactions(t.Context(), filter)
+	if err != nil || len(page) != 1 || page[0].From != b || page[0].Amount.Cmp(model.MustParseMoney("7")) != 0 {
+		t.Errorf("outgoing transfers of the recipient = %+v, %v; want the 7 transfer", page, err)
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"golang-server/internal/tracing"
+	"log/slog"
+	"strings"
+)
+
+// Querier выполняет запросы внутри транзакции; его реализуют *sql.Tx и TracedTx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// TracedTx оборачивает транзакцию так, что каждый запрос выполняется в отдельном span'е
+// с текстом запроса в атрибуте db.statement. По этим span'ам видно, на каком запросе
+// перевод ждёт блокировку при конфликтах Serializable.
+type TracedTx struct {
+	Tx     *sql.Tx
+	System string // Значение атрибута db.system: postgresql или sqlite
+}
+
+// ExecContext выполняет запрос без результата в отдельном span'е.
+func (t TracedTx) ExecContext(ctx context.Context, query string, args ...any) (_ sql.Result, err error) {
+	ctx, span := t.start(ctx, query)
+	defer span.Finish(&err)
+
+	res, err := t.Tx.ExecContext(ctx, query, args...)
+	if err == nil {
+		if n, rowsErr := res.RowsAffected(); rowsErr == nil {
+			span.SetAttributes(slog.Int64("db.rows_affected", n))
+		}
+	}
+	return res, err
+}
+
+// QueryContext выполняет запрос в отдельном span'е. Span покрывает выполнение запроса,
+// но не чтение строк результата.
+func (t TracedTx) QueryContext(ctx context.Context, query string, args ...any) (_ *sql.Rows, err error) {
+	ctx, span := t.start(ctx, query)
+	defer span.Finish(&err)
+
+	return t.Tx.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext выполняет запрос одной строки в отдельном span'е.
+// Отсутствие строки (sql.ErrNoRows) ошибкой span'а не считается.
+func (t TracedTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	span.Finish(&err)
+	return row
+}
+
+// commit фиксирует транзакцию в отдельном span'е: при Serializable конфликт
+// сериализации может обнаружиться только на COMMIT.
+func (t TracedTx) commit(ctx context.Context) (err error) {
+	_, span := t.start(ctx, "COMMIT")
+	defer span.Finish(&err)
+
+	return t.Tx.Commit()
+}
+
+// start начинает span запроса с именем по SQL-команде, например "SELECT".
+func (t TracedTx) start(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(operation)
+	return tracing.Start(ctx, operation, tracing.KindClient,
+		slog.String("db.system", t.System),
+		slog.String("db.operation", operation),
+		slog.String("db.statement", query),
+	)
+}
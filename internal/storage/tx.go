@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"golang-server/internal/config"
+	"golang-server/internal/storage/postgres"
+	"golang-server/internal/tracing"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// SQLSTATE-коды PostgreSQL, при которых транзакцию безопасно повторить.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// Счётчики повторов транзакций, общие для всех TxRunner процесса.
+var (
+	txRetries   atomic.Int64
+	txExhausted atomic.Int64
+)
+
+// TxRetryStats — снимок счётчиков повторов транзакций.
+type TxRetryStats struct {
+	Retries   int64 `json:"retries"`   // Общее число повторов после конфликтов
+	Exhausted int64 `json:"exhausted"` // Число транзакций, исчерпавших лимит повторов
+}
+
+// GetTxRetryStats возвращает текущие значения счётчиков повторов транзакций.
+func GetTxRetryStats() TxRetryStats {
+	return TxRetryStats{
+		Retries:   txRetries.Load(),
+		Exhausted: txExhausted.Load(),
+	}
+}
+
+// TxRunner выполняет функцию в транзакции и повторяет её
+// при ошибках сериализации и взаимоблокировках.
+type TxRunner struct {
+	db         *postgres.PgDB
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// NewTxRunner создаёт TxRunner с параметрами повторов из конфигурации базы данных.
+// Отрицательное значение TxMaxRetries отключает повторы.
+func NewTxRunner(db *postgres.PgDB, cfg config.DbConfig) *TxRunner {
+	return &TxRunner{
+		db:         db,
+		maxRetries: max(cfg.TxMaxRetries, 0),
+		baseDelay:  time.Duration(cfg.TxRetryBaseDelay),
+		maxDelay:   time.Duration(cfg.TxRetryMaxDelay),
+	}
+}
+
+// Run открывает транзакцию с опциями opts, вызывает fn и фиксирует результат.
+// Если fn или Commit вернули ошибку сериализации (40001) или взаимоблокировки (40P01),
+// транзакция откатывается и повторяется с экспоненциальной задержкой и джиттером,
+// но не более maxRetries раз.
+// Параметры:
+//   - ctx: контекст, отмена которого прерывает ожидание между попытками.
+//   - opts: уровень изоляции и режим транзакции.
+//   - fn: тело транзакции; должно быть идемпотентным в пределах одной попытки.
+//     Получает контекст попытки: запросы с ним попадают в span'ы трассировки этой попытки.
+//
+// Возвращает:
+//   - error: ошибку последней попытки или ошибку контекста.
+func (r *TxRunner) Run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	for attempt := 0; ; attempt++ {
+		err := r.runOnce(ctx, attempt, opts, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+		if attempt >= r.maxRetries {
+			txExhausted.Add(1)
+			return err
+		}
+
+		txRetries.Add(1)
+		delay := r.backoff(attempt)
+		slog.WarnContext(ctx, "transaction conflict, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.Int("max_retries", r.maxRetries),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runOnce выполняет одну попытку транзакции в отдельном span'е трассировки.
+func (r *TxRunner) runOnce(ctx context.Context, attempt int, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) (err error) {
+	ctx, span := tracing.Start(ctx, "db.transaction", tracing.KindInternal,
+		slog.String("db.system", "postgresql"),
+		slog.String("db.isolation_level", opts.Isolation.String()),
+		slog.Int("db.transaction.attempt", attempt+1),
+	)
+	defer func() {
+		if err != nil && isRetryable(err) {
+			span.SetAttributes(slog.Bool("db.transaction.retryable", true))
+		}
+		span.Finish(&err)
+	}()
+
+	tx, err := r.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = fn(ctx, tx); err != nil {
+		return err
+	}
+	return TracedTx{Tx: tx, System: "postgresql"}.commit(ctx)
+}
+
+// backoff вычисляет задержку перед повтором: baseDelay * 2^attempt,
+// ограниченную maxDelay, со случайным джиттером в диапазоне [d/2, d).
+func (r *TxRunner) backoff(attempt int) time.Duration {
+	d := r.baseDelay << min(attempt, 16)
+	if d <= 0 || d > r.maxDelay {
+		d = r.maxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+// isRetryable сообщает, является ли ошибка конфликтом сериализации или взаимоблокировкой.
+// Драйвер lib/pq предоставляет SQLSTATE через метод SQLState.
+func isRetryable(err error) bool {
+	var stateErr interface{ SQLState() string }
+	if !errors.As(err, &stateErr) {
+		return false
+	}
+	switch stateErr.SQLState() {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	}
+	return false
+}
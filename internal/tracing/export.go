@@ -0,0 +1,374 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang-server/internal/config"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	queueSize      = 2048             // Максимум span'ов в очереди на экспорт; лишние отбрасываются
+	batchSize      = 512              // Максимум span'ов в одной отправке
+	exportInterval = 5 * time.Second  // Как часто отправляется неполная пачка
+	exportTimeout  = 10 * time.Second // Время на одну отправку
+)
+
+// Exporter отправляет завершённые span'ы во внешнее хранилище.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// newExporter создаёт экспортёр, выбранный в конфигурации; nil для exporter = none.
+func newExporter(cfg config.TracingConfig) (Exporter, error) {
+	switch cfg.Exporter {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return NewWriterExporter(os.Stdout), nil
+	case "file":
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		return NewWriterExporter(f), nil
+	case "otlp":
+		return NewOTLPExporter(cfg.Endpoint, cfg.Headers), nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected none, stdout, file or otlp", cfg.Exporter)
+	}
+}
+
+// batchProcessor копит завершённые span'ы и отправляет их пачками в фоне,
+// чтобы экспорт не задерживал обработку запросов.
+type batchProcessor struct {
+	exporter Exporter
+	queue    chan SpanData
+	dropped  atomic.Int64
+	done     chan struct{}
+
+	// mu защищает queue от записи после закрытия
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newBatchProcessor(exporter Exporter) *batchProcessor {
+	p := &batchProcessor{
+		exporter: exporter,
+		queue:    make(chan SpanData, queueSize),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// enqueue ставит span в очередь на экспорт. При переполненной очереди span отбрасывается.
+func (p *batchProcessor) enqueue(span SpanData) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// run отправляет пачку, когда она заполнена или прошло exportInterval, и досылает остаток при закрытии очереди.
+func (p *batchProcessor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, batchSize)
+	for {
+		select {
+		case span, ok := <-p.queue:
+			if !ok {
+				p.export(batch)
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		p.export(batch)
+		batch = batch[:0]
+	}
+}
+
+func (p *batchProcessor) export(batch []SpanData) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	if err := p.exporter.ExportSpans(ctx, batch); err != nil {
+		slog.Warn("failed to export spans", slog.Int("spans", len(batch)), slog.String("error", err.Error()))
+	}
+}
+
+// shutdown досылает очередь и закрывает экспортёр. Span'ы, завершённые после вызова, теряются.
+func (p *batchProcessor) shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n := p.dropped.Load(); n > 0 {
+		slog.Warn("spans dropped because the export queue was full", slog.Int64("spans", n))
+	}
+	return p.exporter.Shutdown(ctx)
+}
+
+// WriterExporter пишет span'ы построчно в JSON, например в stdout или файл.
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter создаёт экспортёр в w. Если w реализует io.Closer (кроме os.Stdout),
+// он закрывается при Shutdown.
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// writerSpan — запись span'а в выводе WriterExporter.
+type writerSpan struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_span_id,omitempty"`
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	Service    string         `json:"service"`
+	Start      time.Time      `json:"start"`
+	DurationMs float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// ExportSpans реализует Exporter.
+func (e *WriterExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		out := writerSpan{
+			TraceID:    s.TraceID.String(),
+			SpanID:     s.SpanID.String(),
+			Name:       s.Name,
+			Kind:       s.Kind.String(),
+			Service:    s.ServiceName,
+			Start:      s.Start.UTC(),
+			DurationMs: float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			Error:      s.Error,
+		}
+		if s.Parent.IsValid() {
+			out.ParentID = s.Parent.String()
+		}
+		if len(s.Attrs) > 0 {
+			out.Attributes = make(map[string]any, len(s.Attrs))
+			for _, a := range s.Attrs {
+				out.Attributes[a.Key] = a.Value.Resolve().Any()
+			}
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+// Shutdown реализует Exporter.
+func (e *WriterExporter) Shutdown(context.Context) error {
+	if c, ok := e.w.(io.Closer); ok && e.w != os.Stdout {
+		return c.Close()
+	}
+	return nil
+}
+
+// OTLPExporter отправляет span'ы приёмнику OpenTelemetry (collector, Jaeger, Tempo)
+// по протоколу OTLP/HTTP в JSON-кодировке.
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewOTLPExporter создаёт экспортёр в endpoint (обычно http://collector:4318/v1/traces)
+// с дополнительными заголовками headers, например для авторизации.
+func NewOTLPExporter(endpoint string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{endpoint: endpoint, headers: headers, client: &http.Client{}}
+}
+
+// Структуры запроса ExportTraceServiceRequest в JSON-кодировке OTLP.
+// Идентификаторы передаются в шестнадцатеричном виде, 64-битные числа — строками.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		TraceState        string         `json:"traceState,omitempty"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              SpanKind       `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 0 — не задан, 2 — ошибка
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// otlpStatusError — код статуса ERROR в OTLP.
+const otlpStatusError = 2
+
+// ExportSpans реализует Exporter. Span'ы группируются по сервису, как того требует ресурсная модель OTLP.
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	byService := make(map[string][]otlpSpan)
+	var services []string
+	for _, s := range spans {
+		if _, ok := byService[s.ServiceName]; !ok {
+			services = append(services, s.ServiceName)
+		}
+		byService[s.ServiceName] = append(byService[s.ServiceName], toOTLPSpan(s))
+	}
+
+	var req otlpRequest
+	for _, service := range services {
+		req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+			Resource:   otlpResource{Attributes: []otlpKeyValue{toOTLPAttr(slog.String("service.name", service))}},
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "golang-server"}, Spans: byService[service]}},
+		})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown реализует Exporter.
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func toOTLPSpan(s SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.TraceID.String(),
+		SpanID:            s.SpanID.String(),
+		TraceState:        s.TraceState,
+		Name:              s.Name,
+		Kind:              s.Kind,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+	}
+	if s.Parent.IsValid() {
+		span.ParentSpanID = s.Parent.String()
+	}
+	for _, a := range s.Attrs {
+		span.Attributes = append(span.Attributes, toOTLPAttr(a))
+	}
+	if s.Failed {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Error}
+	}
+	return span
+}
+
+// toOTLPAttr преобразует атрибут в OTLP AnyValue; неподдерживаемые типы передаются строкой.
+func toOTLPAttr(a slog.Attr) otlpKeyValue {
+	var v otlpAnyValue
+	value := a.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindBool:
+		b := value.Bool()
+		v.BoolValue = &b
+	case slog.KindInt64:
+		i := strconv.FormatInt(value.Int64(), 10)
+		v.IntValue = &i
+	case slog.KindUint64:
+		i := strconv.FormatUint(value.Uint64(), 10)
+		v.IntValue = &i
+	case slog.KindFloat64:
+		f := value.Float64()
+		v.DoubleValue = &f
+	default:
+		s := value.String()
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: a.Key, Value: v}
+}
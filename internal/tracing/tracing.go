@@ -0,0 +1,302 @@
+// Package tracing — минимальная распределённая трассировка в модели OpenTelemetry без внешних зависимостей:
+// span'ы с атрибутами и статусом, распространение контекста по W3C Trace Context
+// (https://www.w3.org/TR/trace-context/) и экспорт в stdout, файл или приёмник OTLP/HTTP.
+//
+// Пока трассировщик не установлен через SetDefault, Start возвращает nil-span,
+// а все методы Span допускают nil, поэтому инструментированный код не проверяет, включена ли трассировка.
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"golang-server/internal/config"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID — идентификатор трассы.
+type TraceID [16]byte
+
+// SpanID — идентификатор span'а.
+type SpanID [8]byte
+
+// String возвращает идентификатор в шестнадцатеричном виде.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid сообщает, что идентификатор ненулевой.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// String возвращает идентификатор в шестнадцатеричном виде.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid сообщает, что идентификатор ненулевой.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext — часть span'а, которая передаётся между сервисами.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string // Заголовок tracestate передаётся дальше без изменений
+}
+
+// IsValid сообщает, что оба идентификатора заполнены.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind — роль span'а в обмене, как в OTLP.
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1 // Внутренняя операция сервиса
+	KindServer   SpanKind = 2 // Обработка входящего запроса
+	KindClient   SpanKind = 3 // Исходящий запрос, например к базе данных
+)
+
+// String возвращает название роли.
+func (k SpanKind) String() string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	}
+	return "internal"
+}
+
+// Span — одна операция трассы. Методы безопасны для nil и для вызова после End.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+	parent SpanID
+	name   string
+	kind   SpanKind
+	start  time.Time
+
+	mu     sync.Mutex
+	attrs  []slog.Attr
+	err    string
+	failed bool
+	ended  bool
+}
+
+// SpanData — снимок завершённого span'а, который получает Exporter.
+type SpanData struct {
+	SpanContext
+	Parent      SpanID
+	Name        string
+	Kind        SpanKind
+	Start       time.Time
+	End         time.Time
+	Attrs       []slog.Attr
+	Failed      bool
+	Error       string
+	ServiceName string
+}
+
+// SpanContext возвращает идентификаторы span'а.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttributes добавляет атрибуты span'а.
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.attrs = append(s.attrs, attrs...)
+	}
+}
+
+// SetError помечает span как завершившийся ошибкой err.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.failed = true
+		s.err = err.Error()
+	}
+}
+
+// End завершает span и передаёт его на экспорт. Повторные вызовы ничего не делают.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		SpanContext: s.sc,
+		Parent:      s.parent,
+		Name:        s.name,
+		Kind:        s.kind,
+		Start:       s.start,
+		End:         time.Now(),
+		Attrs:       s.attrs,
+		Failed:      s.failed,
+		Error:       s.err,
+		ServiceName: s.tracer.serviceName,
+	}
+	s.mu.Unlock()
+
+	if s.sc.Sampled {
+		s.tracer.processor.enqueue(data)
+	}
+}
+
+// Finish завершает span, отмечая в нём ошибку *errp. Предназначен для defer
+// в функциях с именованной ошибкой:
+//
+//	ctx, span := tracing.Start(ctx, "op", tracing.KindInternal)
+//	defer span.Finish(&err)
+func (s *Span) Finish(errp *error) {
+	if errp != nil {
+		s.SetError(*errp)
+	}
+	s.End()
+}
+
+// Tracer создаёт span'ы и передаёт завершённые на экспорт.
+type Tracer struct {
+	serviceName string
+	sampleRatio float64
+	processor   *batchProcessor
+}
+
+// NewTracer создаёт трассировщик с экспортёром из конфигурации.
+// Параметры:
+//   - cfg: экспортёр, имя сервиса и доля трасс, начинаемых этим сервисом, которые записываются.
+//
+// Возвращает:
+//   - *Tracer: трассировщик или nil, если экспорт отключён (exporter = none).
+//   - error: ошибку, если экспортёр неизвестен или не удалось открыть файл.
+func NewTracer(cfg config.TracingConfig) (*Tracer, error) {
+	exporter, err := newExporter(cfg)
+	if err != nil || exporter == nil {
+		return nil, err
+	}
+	return &Tracer{
+		serviceName: cfg.ServiceName,
+		sampleRatio: cfg.SampleRatio,
+		processor:   newBatchProcessor(exporter),
+	}, nil
+}
+
+// Shutdown экспортирует накопленные span'ы и освобождает ресурсы экспортёра.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.processor.shutdown(ctx)
+}
+
+// defaultTracer — трассировщик процесса; nil, пока трассировка не включена.
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault устанавливает трассировщик процесса, которым пользуется Start.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// contextKey — ключи значений пакета в контексте.
+type contextKey int
+
+const (
+	spanKey contextKey = iota
+	remoteKey
+)
+
+// Start начинает span name, дочерний к span'у из ctx (или к удалённому родителю,
+// извлечённому Extract), и возвращает контекст с новым span'ом.
+// Если трассировка выключена, возвращает ctx без изменений и nil-span.
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...slog.Attr) (context.Context, *Span) {
+	t := defaultTracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		// Решение о записи принимает начало трассы, как ParentBased-сэмплер OpenTelemetry
+		sc.TraceID, sc.Sampled, sc.TraceState = parent.TraceID, parent.Sampled, parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = t.sample(sc.TraceID)
+	}
+
+	span := &Span{
+		tracer: t,
+		sc:     sc,
+		parent: parent.SpanID,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// SpanFromContext возвращает текущий span или nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// SpanContextFromContext возвращает идентификаторы текущего span'а,
+// а если его нет — удалённого родителя из входящего запроса.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc
+	}
+	sc, _ := ctx.Value(remoteKey).(SpanContext)
+	return sc
+}
+
+// ContextWithRemoteSpanContext возвращает контекст с удалённым родителем sc.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey, sc)
+}
+
+// sample решает, записывать ли новую трассу. Решение зависит только от идентификатора,
+// как у TraceIDRatioBased-сэмплера OpenTelemetry.
+func (t *Tracer) sample(id TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+	binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordingExporter запоминает экспортированные span'ы.
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *recordingExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(context.Context) error { return nil }
+
+// useTracer устанавливает трассировщик с exporter на время теста.
+func useTracer(t *testing.T, exporter Exporter, sampleRatio float64) *Tracer {
+	t.Helper()
+
+	tracer := &Tracer{serviceName: "test", sampleRatio: sampleRatio, processor: newBatchProcessor(exporter)}
+	SetDefault(tracer)
+	t.Cleanup(func() { SetDefault(nil) })
+	return tracer
+}
+
+func TestTraceparent(t *testing.T) {
+	valid := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := parseTraceparent(valid)
+	if !ok || !sc.Sampled || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("parseTraceparent(%q) = %+v, %v", valid, sc, ok)
+	}
+	if got := formatTraceparent(sc); got != valid {
+		t.Errorf("formatTraceparent = %q, want %q", got, valid)
+	}
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, v := range invalid {
+		if sc, ok := parseTraceparent(v); ok {
+			t.Errorf("parseTraceparent(%q) = %+v, want invalid", v, sc)
+		}
+	}
+
+	// Будущие версии разбираются по полям версии 00
+	if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"); !ok {
+		t.Error("traceparent of a future version was rejected")
+	}
+}
+
+func TestStartDisabled(t *testing.T) {
+	ctx, span := Start(t.Context(), "op", KindInternal)
+	if span != nil || ctx != t.Context() {
+		t.Fatalf("Start without a tracer returned span %v", span)
+	}
+	// Методы nil-span ничего не делают
+	span.SetAttributes(slog.String("k", "v"))
+	err := errors.New("failed")
+	span.Finish(&err)
+}
+
+func TestSpansContinueRemoteTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := useTracer(t, exporter, 1)
+
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Set(TracestateHeader, "vendor=value")
+	ctx := Extract(t.Context(), h)
+
+	ctx, parent := Start(ctx, "parent", KindServer)
+	_, child := Start(ctx, "child", KindClient, slog.String("db.statement", "SELECT 1"))
+	err := errors.New("boom")
+	child.Finish(&err)
+	parent.End()
+	parent.End()
+
+	out := http.Header{}
+	Inject(ctx, out)
+	if got := out.Get(TraceparentHeader); got != formatTraceparent(parent.SpanContext()) {
+		t.Errorf("injected traceparent = %q", got)
+	}
+	if got := out.Get(TracestateHeader); got != "vendor=value" {
+		t.Errorf("injected tracestate = %q, want vendor=value", got)
+	}
+
+	if err := tracer.Shutdown(t.Context()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(exporter.spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(exporter.spans))
+	}
+	c, p := exporter.spans[0], exporter.spans[1]
+	if p.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || p.Parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("parent span = %+v, want a child of the remote span", p)
+	}
+	if c.TraceID != p.TraceID || c.Parent != p.SpanID {
+		t.Errorf("child span = %+v, want a child of %s", c, p.SpanID)
+	}
+	if !c.Failed || c.Error != "boom" || len(c.Attrs) != 1 {
+		t.Errorf("child span = %+v, want failed with one attribute", c)
+	}
+}
+
+func TestNotSampledTraceIsNotExported(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := useTracer(t, exporter, 1)
+
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span := Start(Extract(t.Context(), h), "op", KindServer)
+	span.End()
+
+	if err := tracer.Shutdown(t.Context()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(exporter.spans) != 0 {
+		t.Errorf("exported %d spans of a trace the caller does not sample", len(exporter.spans))
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body otlpRequest
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request body is not OTLP JSON: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	exporter := NewOTLPExporter(srv.URL, map[string]string{"Authorization": "Bearer token"})
+	tracer := useTracer(t, exporter, 1)
+	_, span := Start(t.Context(), "GET /api/transactions", KindServer, slog.Int("http.response.status_code", 200))
+	span.End()
+	if err := tracer.Shutdown(t.Context()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if auth != "Bearer token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if len(body.ResourceSpans) != 1 || len(body.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected OTLP request: %+v", body)
+	}
+	spans := body.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "GET /api/transactions" || spans[0].Kind != KindServer || len(spans[0].TraceID) != 32 {
+		t.Fatalf("unexpected OTLP spans: %+v", spans)
+	}
+	if attr := spans[0].Attributes[0]; attr.Value.IntValue == nil || *attr.Value.IntValue != "200" {
+		t.Errorf("status code attribute = %+v, want intValue 200", attr)
+	}
+}
@@ -1,28 +1,117 @@
 package validation
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
-	"strconv"
+	"fmt"
+	"github.com/google/uuid"
+	"golang-server/internal/model"
 )
 
-// ValidateAmount проверяет корректность переданной строки с суммой.
-// Функция выполняет следующие проверки:
-// 1. Преобразует строку в число с плавающей запятой.
-// 2. Проверяет, что сумма больше нуля.
+const (
+	maxOwnerRefLength = 255  // Максимальная длина внешней ссылки на владельца кошелька
+	maxReasonLength   = 1024 // Максимальная длина причины смены статуса
+	maxKeyNameLength  = 255  // Максимальная длина имени ключа API
+	maxKeyWallets     = 1000 // Максимальное количество кошельков одного ключа API
+)
+
+// ValidateAmount проверяет корректность суммы перевода.
+// Формат и точность суммы проверяются при разборе model.Money,
+// здесь остаётся проверка, что сумма больше нуля.
 // Параметры:
-//   - amount: строковое представление суммы, которую нужно проверить.
+//   - amount: сумма, которую нужно проверить.
 //
 // Возвращает:
-//   - error: ошибку в случае некорректного формата или если сумма меньше или равна нулю.
+//   - error: ошибку, если сумма меньше или равна нулю.
 //     Возвращает nil, если сумма корректна.
-func ValidateAmount(amount string) error {
-	amountFloat, err := strconv.ParseFloat(amount, 64)
-	if err != nil {
-		return errors.New("invalid amount: must be a number")
-	}
-	if amountFloat <= 0 {
+func ValidateAmount(amount model.Money) error {
+	if amount.Sign() <= 0 {
 		return errors.New("amount must be greater than zero")
 	}
 
 	return nil
 }
+
+// ValidateCreateWallet проверяет параметры создания кошелька.
+// Начальный баланс не может быть отрицательным, owner_ref ограничен по длине,
+// metadata, если передан, должен быть JSON-объектом.
+// Параметры:
+//   - req: запрос на создание кошелька.
+//
+// Возвращает:
+//   - error: описание первого найденного нарушения или nil.
+func ValidateCreateWallet(req model.CreateWalletRequest) error {
+	if req.OpeningBalance.Sign() < 0 {
+		return errors.New("opening_balance must not be negative")
+	}
+	if req.OwnerRef != nil && len(*req.OwnerRef) > maxOwnerRefLength {
+		return errors.New("owner_ref is too long")
+	}
+	if len(req.Metadata) > 0 && !bytes.Equal(req.Metadata, []byte("null")) {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(req.Metadata, &obj); err != nil {
+			return errors.New("metadata must be a JSON object")
+		}
+	}
+
+	return nil
+}
+
+// ValidateWalletStatusChange проверяет запрос на смену статуса кошелька.
+// Параметры:
+//   - req: запрос со статусом и причиной.
+//   - requireAudit: требовать непустую reason (для административных запросов).
+//
+// Возвращает:
+//   - error: описание первого найденного нарушения или nil.
+func ValidateWalletStatusChange(req model.UpdateWalletRequest, requireAudit bool) error {
+	if !req.Status.Valid() {
+		return errors.New("invalid wallet status")
+	}
+	if len(req.Reason) > maxReasonLength {
+		return errors.New("reason is too long")
+	}
+	if requireAudit && req.Reason == "" {
+		return errors.New("reason is required")
+	}
+
+	return nil
+}
+
+// ValidateIssueAPIKey проверяет запрос на выпуск ключа API.
+// Ключ клиента должен быть привязан хотя бы к одному кошельку без повторов,
+// ключ администратора имеет доступ ко всем кошелькам и список не принимает.
+// Параметры:
+//   - req: запрос с именем, ролью и кошельками ключа.
+//
+// Возвращает:
+//   - error: описание первого найденного нарушения или nil.
+func ValidateIssueAPIKey(req model.IssueAPIKeyRequest) error {
+	if req.Name == "" || len(req.Name) > maxKeyNameLength {
+		return fmt.Errorf("name must be between 1 and %d characters", maxKeyNameLength)
+	}
+	if !req.Role.Valid() {
+		return errors.New("invalid API key role")
+	}
+
+	if req.Role == model.APIKeyAdmin {
+		if len(req.Wallets) > 0 {
+			return errors.New("admin key must not list wallets")
+		}
+		return nil
+	}
+
+	if len(req.Wallets) == 0 || len(req.Wallets) > maxKeyWallets {
+		return fmt.Errorf("client key must list between 1 and %d wallets", maxKeyWallets)
+	}
+	seen := make(map[uuid.UUID]bool, len(req.Wallets))
+	for _, id := range req.Wallets {
+		if seen[id] {
+			return fmt.Errorf("wallet %s is listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}